@@ -13,7 +13,12 @@ NC='\033[0m' # No Color
 
 OUTPUT_DIR="../frontend/src-tauri/binaries"
 BINARY_NAME="website-mover-backend"
-SRC="./cmd/server/main.go"
+SRC="./cmd/server"
+
+VERSION="${VERSION:-$(git describe --tags --always --dirty 2>/dev/null || echo dev)}"
+COMMIT="${COMMIT:-$(git rev-parse --short HEAD 2>/dev/null || echo unknown)}"
+BUILD_TIME="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+LDFLAGS="-X main.Version=${VERSION} -X main.Commit=${COMMIT} -X main.BuildTime=${BUILD_TIME}"
 
 echo -e "${GREEN}🔨 Building Website Mover Backend...${NC}\n"
 
@@ -22,17 +27,17 @@ mkdir -p "$OUTPUT_DIR"
 
 # Build for macOS ARM (Apple Silicon)
 echo -e "${YELLOW}Building for macOS ARM (aarch64-apple-darwin)...${NC}"
-GOOS=darwin GOARCH=arm64 go build -o "$OUTPUT_DIR/${BINARY_NAME}-aarch64-apple-darwin" "$SRC"
+GOOS=darwin GOARCH=arm64 go build -ldflags "$LDFLAGS" -o "$OUTPUT_DIR/${BINARY_NAME}-aarch64-apple-darwin" "$SRC"
 echo -e "${GREEN}✓ macOS ARM build complete${NC}\n"
 
 # Build for macOS Intel (x86_64)
 echo -e "${YELLOW}Building for macOS Intel (x86_64-apple-darwin)...${NC}"
-GOOS=darwin GOARCH=amd64 go build -o "$OUTPUT_DIR/${BINARY_NAME}-x86_64-apple-darwin" "$SRC"
+GOOS=darwin GOARCH=amd64 go build -ldflags "$LDFLAGS" -o "$OUTPUT_DIR/${BINARY_NAME}-x86_64-apple-darwin" "$SRC"
 echo -e "${GREEN}✓ macOS Intel build complete${NC}\n"
 
 # Build for Linux x86_64
 echo -e "${YELLOW}Building for Linux x86_64 (x86_64-unknown-linux-gnu)...${NC}"
-GOOS=linux GOARCH=amd64 go build -o "$OUTPUT_DIR/${BINARY_NAME}-x86_64-unknown-linux-gnu" "$SRC"
+GOOS=linux GOARCH=amd64 go build -ldflags "$LDFLAGS" -o "$OUTPUT_DIR/${BINARY_NAME}-x86_64-unknown-linux-gnu" "$SRC"
 echo -e "${GREEN}✓ Linux x86_64 build complete${NC}\n"
 
 # List built binaries
@@ -2,30 +2,91 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"golang.org/x/crypto/ssh"
 	
+	"github.com/gonzague/website-mover/backend/internal/config"
+	"github.com/gonzague/website-mover/backend/internal/constants"
+	"github.com/gonzague/website-mover/backend/internal/notify"
 	"github.com/gonzague/website-mover/backend/internal/rclone"
+	"github.com/gonzague/website-mover/backend/internal/sshutil"
+	"github.com/gonzague/website-mover/backend/internal/tlsutil"
+	"github.com/gonzague/website-mover/backend/internal/util"
+	"github.com/gonzague/website-mover/backend/internal/validation"
 )
 
 type Server struct {
 	configManager *rclone.ConfigManager
 	executor      *rclone.Executor
 	historyStore  *rclone.HistoryStore
+	config        *config.ServerConfig
+	
+	// Rclone version detected at startup
+	rcloneVersion    string
+	rcloneVersionErr error
 	
 	// Track active jobs
 	activeJobs map[string]*rclone.MigrationJob
 	jobsMux    sync.RWMutex
+	
+	// Track post-migration verifications, with the cancel functions of the
+	// running ones so shutdown can stop them
+	verifyJobs    map[string]*rclone.VerifyJob
+	verifyCancels map[string]context.CancelFunc
+	verifyMux     sync.RWMutex
+	verifyWG      sync.WaitGroup
+
+	// Track batch migrations to several destinations
+	batchJobs map[string]*rclone.BatchJob
+	batchMux  sync.RWMutex
+
+	// Last result of testing all remotes, and a lock held while refreshing it
+	remoteHealth           *rclone.HealthReport
+	remoteHealthMux        sync.RWMutex
+	remoteHealthRefreshMux sync.Mutex
+
+	// Held while numbering and starting a retry, so that concurrent retries
+	// of the same migration get distinct counts
+	retryMux sync.Mutex
+	
+	// Admin token for protected endpoints (empty disables them)
+	adminToken string
+	
+	// Proxies allowed to set X-Real-IP and X-Forwarded-For
+	trustedProxies []*net.IPNet
+	
+	// Receives OS signals or programmatic shutdown requests
+	shutdownSignal chan os.Signal
 }
 
 func main() {
+	serverConfig, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+
 	// Initialize components
 	configManager, err := rclone.NewConfigManager("")
 	if err != nil {
@@ -38,339 +99,2000 @@ func main() {
 	}
 
 	executor := rclone.NewExecutor(configManager.GetConfigPath())
+	executor.MemoryLimitMB = serverConfig.MaxMemoryMB
+	sshutil.SetHostConnectionLimits(serverConfig.HostConnectionLimits)
 
 	server := &Server{
-		configManager: configManager,
-		executor:      executor,
-		historyStore:  historyStore,
-		activeJobs:    make(map[string]*rclone.MigrationJob),
+		configManager:  configManager,
+		executor:       executor,
+		historyStore:   historyStore,
+		config:         serverConfig,
+		activeJobs:     make(map[string]*rclone.MigrationJob),
+		verifyJobs:     make(map[string]*rclone.VerifyJob),
+		verifyCancels:  make(map[string]context.CancelFunc),
+		batchJobs:      make(map[string]*rclone.BatchJob),
+		adminToken:     os.Getenv("WEBSITE_MOVER_ADMIN_TOKEN"),
+		trustedProxies: parseTrustedProxies(os.Getenv("WEBSITE_MOVER_TRUSTED_PROXIES")),
+		shutdownSignal: make(chan os.Signal, 1),
+	}
+
+	// Detect rclone version
+	versionCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	server.rcloneVersion, server.rcloneVersionErr = executor.DetectRcloneVersion(versionCtx)
+	cancel()
+	if server.rcloneVersionErr != nil {
+		log.Printf("WARNING: Failed to detect rclone version: %v", server.rcloneVersionErr)
+	} else if err := rclone.CheckRcloneVersion(server.rcloneVersion, constants.MinRcloneVersion); err != nil {
+		log.Printf("WARNING: %v", err)
 	}
 
 	// Setup router
 	router := mux.NewRouter()
 	
+	// System endpoints
+	router.HandleFunc("/api/health", server.handleHealth).Methods("GET")
+	router.HandleFunc("/api/version", server.handleVersion).Methods("GET")
+	router.HandleFunc("/api/system/memory", server.handleSystemMemory).Methods("GET")
+	router.HandleFunc("/api/rclone/version", server.handleRcloneVersion).Methods("GET")
+	
 	// Remotes endpoints
 	router.HandleFunc("/api/remotes", server.handleListRemotes).Methods("GET")
 	router.HandleFunc("/api/remotes", server.handleAddRemote).Methods("POST")
+	router.HandleFunc("/api/remotes/test-all", server.handleTestAllRemotes).Methods("POST")
+	router.HandleFunc("/api/remotes/health", server.handleRemotesHealth).Methods("GET")
+	router.HandleFunc("/api/remotes/{name}", server.handleGetRemote).Methods("GET")
+	router.HandleFunc("/api/remotes/{name}", server.handlePutRemote).Methods("PUT")
 	router.HandleFunc("/api/remotes/{name}", server.handleDeleteRemote).Methods("DELETE")
-	router.HandleFunc("/api/remotes/test", server.handleTestRemote).Methods("POST")
+	router.HandleFunc("/api/remotes/{name}/rename", server.handleRenameRemote).Methods("POST")
+	router.HandleFunc("/api/remotes/{name}/test", server.handleTestRemote).Methods("GET", "POST")
 	router.HandleFunc("/api/remotes/{name}/list", server.handleListPath).Methods("GET")
+	router.HandleFunc("/api/remotes/{name}/browse-rich", server.handleBrowseRich).Methods("GET")
+	router.HandleFunc("/api/remotes/{name}/key-info", server.handleRemoteKeyInfo).Methods("GET")
+	router.HandleFunc("/api/remotes/{name}/upload", server.handleUploadFile).Methods("POST")
+	router.HandleFunc("/api/remotes/{name}/files/{path}/preview", server.handlePreviewFile).Methods("GET")
+	
+	// Config endpoints
+	router.HandleFunc("/api/config/import", server.handleImportConfig).Methods("POST")
+	router.HandleFunc("/api/config/export", server.handleExportConfig).Methods("POST")
+	router.HandleFunc("/api/config/backup", server.handleBackupConfig).Methods("POST")
+	router.HandleFunc("/api/config/restore", server.handleRestoreConfig).Methods("POST")
+	
+	// SSH endpoints
+	router.HandleFunc("/api/ssh/validate-key", server.handleValidateSSHKey).Methods("GET", "POST")
+	router.HandleFunc("/api/diagnose", server.handleDiagnose).Methods("POST")
+	router.HandleFunc("/api/ssl/check", server.handleCheckSSL).Methods("GET")
+	router.HandleFunc("/api/excludes/import-gitignore", server.handleImportGitignore).Methods("POST")
+	
+	// Notification endpoints
+	router.HandleFunc("/api/notify/test-email", server.handleTestEmail).Methods("POST")
 	
 	// Migration endpoints
 	router.HandleFunc("/api/migrations", server.handleStartMigration).Methods("POST")
 	router.HandleFunc("/api/migrations", server.handleListMigrations).Methods("GET")
+	router.HandleFunc("/api/migrations/incremental", server.handleStartIncrementalMigration).Methods("POST")
+	router.HandleFunc("/api/migrations/batch", server.handleStartBatchMigration).Methods("POST")
+	router.HandleFunc("/api/migrations/batch/{id}", server.handleGetBatchMigration).Methods("GET")
 	router.HandleFunc("/api/migrations/{id}/stream", server.handleStreamMigration).Methods("GET")
+	router.HandleFunc("/api/migrations/{id}/cancel", server.handleCancelMigration).Methods("POST")
+	router.HandleFunc("/api/migrations/{id}/retry", server.handleRetryMigration).Methods("POST")
+	router.HandleFunc("/api/migrations/{id}/cleanup", server.handleCleanupMigration).Methods("POST")
+	router.HandleFunc("/api/migrations/{id}/stats", server.handleGetMigrationStats).Methods("GET")
+	router.HandleFunc("/api/migrations/{id}/dry-run-report", server.handleGetDryRunReport).Methods("GET")
+	router.HandleFunc("/api/migrations/{id}/dry-run-report/csv", server.handleGetDryRunReportCSV).Methods("GET")
+	router.HandleFunc("/api/migrations/{id}/diff", server.handleGetDryRunDiff).Methods("GET")
+	router.HandleFunc("/api/migrations/{id}/diff.json", server.handleGetDryRunDiffJSON).Methods("GET")
+	router.HandleFunc("/api/migrations/{id}/verify", server.handleVerifyMigration).Methods("POST")
+	router.HandleFunc("/api/verifications/{id}", server.handleGetVerification).Methods("GET")
 	router.HandleFunc("/api/migrations/active", server.handleListActiveJobs).Methods("GET")
 	
+	// Admin endpoints
+	router.HandleFunc("/api/admin/shutdown", server.requireAdmin(server.handleAdminShutdown)).Methods("GET")
+	router.HandleFunc("/api/admin/active-sessions", server.requireAdmin(server.handleActiveSessions)).Methods("GET")
+	
 	// History endpoints
 	router.HandleFunc("/api/history", server.handleListHistory).Methods("GET")
 	router.HandleFunc("/api/history", server.handleClearHistory).Methods("DELETE")
+	router.HandleFunc("/api/history/stats", server.handleHistoryStats).Methods("GET")
+	router.HandleFunc("/api/history/summary", server.handleHistorySummary).Methods("GET")
+	router.HandleFunc("/api/history/export.csv", server.handleExportHistoryCSV).Methods("GET")
 	router.HandleFunc("/api/history/{id}", server.handleGetHistory).Methods("GET")
+	router.HandleFunc("/api/history/{id}", server.handleDeleteHistory).Methods("DELETE")
+	router.HandleFunc("/api/history/{id}/replay", server.handleReplayHistory).Methods("POST")
+	router.HandleFunc("/api/history/{id}/export.csv", server.handleExportHistoryEntryCSV).Methods("GET")
+	router.HandleFunc("/api/history/{id}/export.gitignore", server.handleExportHistoryGitignore).Methods("GET")
+	router.HandleFunc("/api/history/{id}/replay-feasibility", server.handleReplayFeasibility).Methods("GET")
 
 	// CORS
+	originScheme := "http"
+	if serverConfig.TLSEnabled() {
+		originScheme = "https"
+	}
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173", "http://localhost:3000"},
+		AllowedOrigins:   []string{originScheme + "://localhost:5173", originScheme + "://localhost:3000"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{util.RequestIDHeader},
 		AllowCredentials: true,
 	})
 
-	handler := c.Handler(router)
+	handler := requestIDMiddleware(c.Handler(router))
 
 	// Start server
-	port := ":8080"
-	log.Printf("Server starting on %s", port)
-	log.Printf("Rclone config: %s", configManager.GetConfigPath())
-	
-	if err := http.ListenAndServe(port, handler); err != nil {
-		log.Fatal(err)
+	certFile, keyFile := serverConfig.TLSCert, serverConfig.TLSKey
+	if serverConfig.TLSSelfSigned {
+		certFile, keyFile, err = config.EnsureSelfSignedCert()
+		if err != nil {
+			log.Fatalf("Failed to prepare self-signed certificate: %v", err)
+		}
+		log.Printf("Using self-signed certificate %s (browsers will ask for a trust exception)", certFile)
 	}
-}
 
-// handleListRemotes returns all configured remotes
-func (s *Server) handleListRemotes(w http.ResponseWriter, r *http.Request) {
-	remotes, err := s.configManager.ListRemotes()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	httpServer := &http.Server{
+		Addr:    serverConfig.BindAddress,
+		Handler: handler,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"remotes": remotes,
-	})
-}
-
-// handleAddRemote adds or updates a remote
-func (s *Server) handleAddRemote(w http.ResponseWriter, r *http.Request) {
-	var remote rclone.Remote
-	if err := json.NewDecoder(r.Body).Decode(&remote); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+	signal.Notify(server.shutdownSignal, syscall.SIGINT, syscall.SIGTERM)
 
-	if err := s.configManager.AddRemote(remote); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	go func() {
+		log.Printf("Server starting on %s", serverConfig.URL())
+		log.Printf("Rclone config: %s", configManager.GetConfigPath())
+		
+		var err error
+		if serverConfig.TLSEnabled() {
+			err = httpServer.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Remote %s configured successfully", remote.Name),
-	})
+	sig := <-server.shutdownSignal
+	log.Printf("Received %s, shutting down", sig)
+	server.shutdown(httpServer)
 }
 
-// handleDeleteRemote deletes a remote
-func (s *Server) handleDeleteRemote(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	name := vars["name"]
+// shutdown stops accepting requests, waits for active migrations to finish
+// and records their final state in history
+func (s *Server) shutdown(httpServer *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.ShutdownTimeout)
+	defer cancel()
 
-	if err := s.configManager.DeleteRemote(name); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if err := httpServer.Shutdown(ctx); err != nil {
+		// Long-lived SSE streams keep connections busy; close them forcibly
+		log.Printf("HTTP server shutdown: %v", err)
+		httpServer.Close()
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": fmt.Sprintf("Remote %s deleted", name),
-	})
-}
+	s.cancelVerifications()
 
-// handleTestRemote tests connectivity to a remote
-func (s *Server) handleTestRemote(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		RemoteName string `json:"remote_name"`
-		Path       string `json:"path"`
-	}
-	
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	s.jobsMux.RLock()
+	jobs := make([]*rclone.MigrationJob, 0, len(s.activeJobs))
+	for _, job := range s.activeJobs {
+		jobs = append(jobs, job)
 	}
+	s.jobsMux.RUnlock()
 
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+	if len(jobs) > 0 {
+		log.Printf("Waiting for %d active migration(s) to complete", len(jobs))
+	}
 
-	result := s.executor.TestRemote(ctx, req.RemoteName, req.Path)
+	for _, job := range jobs {
+		if !job.WaitForCompletion(constants.JobDrainTimeout) {
+			logRequestID(job.RequestID, "Migration %s did not complete in time (status: %s)", job.ID, job.GetStatus())
+		}
+		s.finalizeJob(job)
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	log.Printf("Shutdown complete")
 }
 
-// handleListPath lists files in a remote path
-func (s *Server) handleListPath(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	remoteName := vars["name"]
-	path := r.URL.Query().Get("path")
-	if path == "" {
-		path = "" // Root
-	}
-
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+// finalizeJob records a job in history and stops tracking it. It is safe to
+// call more than once; only the first call writes the history entry.
+func (s *Server) finalizeJob(job *rclone.MigrationJob) {
+	s.jobsMux.Lock()
+	defer s.jobsMux.Unlock()
 
-	items, err := s.executor.ListPath(ctx, remoteName, path)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if _, tracked := s.activeJobs[job.ID]; !tracked {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"items": items,
-	})
+	if err := s.historyStore.Add(job, time.Now()); err != nil {
+		logRequestID(job.RequestID, "Failed to add job %s to history: %v", job.ID, err)
+	} else {
+		if cfg := job.Options.EmailConfig; cfg != nil && cfg.To != "" {
+			go s.sendMigrationEmail(job.RequestID, job.ID, *cfg)
+		}
+		if needsCleanup(job) {
+			go s.cleanupPartialFiles(job.RequestID, job.ID, job.Options, job.StartTime)
+		}
+	}
+
+	delete(s.activeJobs, job.ID)
 }
 
-// handleStartMigration starts a new migration
-func (s *Server) handleStartMigration(w http.ResponseWriter, r *http.Request) {
-	var opts rclone.MigrationOptions
-	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+// needsCleanup reports whether a finished job's partial uploads should be
+// removed automatically: it failed or was cancelled, wrote files, and asked
+// for cleanup
+func needsCleanup(job *rclone.MigrationJob) bool {
+	status := job.GetStatus()
+	return job.Options.CleanupOnFailure && !job.Options.DryRun &&
+		(status == "failed" || status == "cancelled")
+}
 
-	// Set defaults
-	if opts.Transfers == 0 {
-		opts.Transfers = 8
-	}
-	if opts.Checkers == 0 {
-		opts.Checkers = 8
+// cleanupPartialFiles removes the partial uploads of a failed migration and
+// records the result in its history entry. requestID is the request that
+// started the migration or asked for the cleanup, for the logs.
+func (s *Server) cleanupPartialFiles(requestID, id string, opts rclone.MigrationOptions, startTime time.Time) *rclone.CleanupResult {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.CleanupTimeout)
+	defer cancel()
+
+	result := s.executor.CleanupPartialFiles(ctx, opts, startTime)
+	if err := s.historyStore.SetCleanup(id, result); err != nil {
+		logRequestID(requestID, "Failed to record cleanup of %s in history: %v", id, err)
 	}
+	return result
+}
 
-	// Use background context so migration continues after HTTP response
-	job, err := s.executor.StartMigration(context.Background(), opts)
+// sendMigrationEmail emails the history entry of a finished migration;
+// requestID is the request that started it
+func (s *Server) sendMigrationEmail(requestID, id string, cfg rclone.EmailConfig) {
+	history, err := s.historyStore.Get(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logRequestID(requestID, "Failed to load history for email notification of %s: %v", id, err)
 		return
 	}
 
-	// Track job
-	s.jobsMux.Lock()
-	s.activeJobs[job.ID] = job
-	s.jobsMux.Unlock()
+	if err := notify.SendMigrationEmail(history, cfg); err != nil {
+		logRequestID(requestID, "Failed to send email notification for %s: %v", id, err)
+	}
+}
 
-	// Monitor job completion
-	go func() {
-		// Wait for job to complete
-		for job.Status == "running" {
-			time.Sleep(1 * time.Second)
+// requireAdmin protects a handler with the admin token from
+// WEBSITE_MOVER_ADMIN_TOKEN, sent as "Authorization: Bearer <token>"
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			http.Error(w, "Admin endpoints are disabled (set WEBSITE_MOVER_ADMIN_TOKEN)", http.StatusForbidden)
+			return
 		}
-		
-		// Add to history
-		if err := s.historyStore.Add(job, time.Now()); err != nil {
-			log.Printf("Failed to add job to history: %v", err)
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
 		}
 
-		// Remove from active jobs
-		s.jobsMux.Lock()
-		delete(s.activeJobs, job.ID)
-		s.jobsMux.Unlock()
-	}()
+		next(w, r)
+	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"job_id":  job.ID,
-		"command": job.Command,
-		"status":  job.Status,
+// requestIDMiddleware tags every request with an ID, taken from a valid
+// X-Request-ID header or generated, returns it in the response and logs it
+// with error responses
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := util.RequestID(r.Header.Get(util.RequestIDHeader))
+		w.Header().Set(util.RequestIDHeader, id)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(util.WithRequestID(r.Context(), id)))
+
+		if recorder.status >= http.StatusBadRequest {
+			logRequestID(id, "%s %s returned %d", r.Method, r.URL.Path, recorder.status)
+		}
 	})
 }
 
-// handleStreamMigration streams migration output via SSE
-func (s *Server) handleStreamMigration(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	jobID := vars["id"]
+// statusRecorder remembers the status code written by a handler. It keeps
+// http.Flusher working so SSE streams are not buffered.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
 
-	// Find job
-	s.jobsMux.RLock()
-	job, exists := s.activeJobs[jobID]
-	s.jobsMux.RUnlock()
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
 
-	if !exists {
-		http.Error(w, "Job not found", http.StatusNotFound)
-		return
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
 	}
+}
 
-	// Setup SSE
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
-		return
-	}
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
 
-	// Subscribe to job output
-	ch := job.Subscribe()
+// logRequest logs a line prefixed with the ID of the request it belongs to
+func logRequest(r *http.Request, format string, args ...interface{}) {
+	logRequestID(util.GetRequestID(r.Context()), format, args...)
+}
 
-	for {
-		select {
-		case <-r.Context().Done():
-			return
-		case event, ok := <-ch:
-			if !ok {
-				// Channel closed, job completed
-				fmt.Fprintf(w, "data: {\"type\":\"complete\",\"status\":\"%s\"}\n\n", job.Status)
-				flusher.Flush()
-				return
-			}
-			
-			// Send event
-			data, _ := json.Marshal(event)
-			fmt.Fprintf(w, "data: %s\n\n", data)
-			flusher.Flush()
-		}
+// logRequestID logs a line prefixed with a request ID, for work that outlives
+// its request such as a migration finishing
+func logRequestID(id string, format string, args ...interface{}) {
+	if id != "" {
+		format = "[" + id + "] " + format
 	}
+	log.Printf(format, args...)
 }
 
-// handleListActiveJobs lists currently running jobs
-func (s *Server) handleListActiveJobs(w http.ResponseWriter, r *http.Request) {
-	s.jobsMux.RLock()
-	defer s.jobsMux.RUnlock()
-
-	jobs := []map[string]interface{}{}
-	for _, job := range s.activeJobs {
-		jobs = append(jobs, map[string]interface{}{
-			"id":         job.ID,
-			"command":    job.Command,
-			"start_time": job.StartTime,
-			"status":     job.Status,
-		})
+// handleAdminShutdown triggers a graceful shutdown
+func (s *Server) handleAdminShutdown(w http.ResponseWriter, r *http.Request) {
+	select {
+	case s.shutdownSignal <- syscall.SIGTERM:
+	default:
+		// Shutdown already requested
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"jobs": jobs,
+		"success": true,
+		"message": "Shutdown initiated",
 	})
 }
 
-// handleListMigrations lists all migrations (active + history)
-func (s *Server) handleListMigrations(w http.ResponseWriter, r *http.Request) {
-	// Get history
-	history, err := s.historyStore.List()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Get active jobs
+// handleActiveSessions lists running migrations with who started them and their progress
+func (s *Server) handleActiveSessions(w http.ResponseWriter, r *http.Request) {
 	s.jobsMux.RLock()
-	activeJobs := make([]map[string]interface{}, 0, len(s.activeJobs))
+	sessions := make([]map[string]interface{}, 0, len(s.activeJobs))
 	for _, job := range s.activeJobs {
-		activeJobs = append(activeJobs, map[string]interface{}{
+		sessions = append(sessions, map[string]interface{}{
 			"id":         job.ID,
-			"command":    job.Command,
+			"status":     job.GetStatus(),
 			"start_time": job.StartTime,
-			"status":     job.Status,
-			"options":    job.Options,
+			"client_ip":  job.ClientIP,
+			"user_agent": job.UserAgent,
+			"options":    job.Options.WithoutSecrets(),
+			"stats":      job.GetStats(),
 		})
 	}
 	s.jobsMux.RUnlock()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"active":  activeJobs,
-		"history": history,
+		"sessions": sessions,
 	})
 }
 
-// handleListHistory lists migration history
-func (s *Server) handleListHistory(w http.ResponseWriter, r *http.Request) {
-	history, err := s.historyStore.List()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+// parseTrustedProxies parses a comma-separated list of IPs and CIDR ranges
+func parseTrustedProxies(value string) []*net.IPNet {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("WARNING: Ignoring invalid trusted proxy %q", entry)
+			continue
+		}
+		proxies = append(proxies, network)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"history": history,
-	})
+	return proxies
 }
 
-// handleClearHistory clears all migration history
-func (s *Server) handleClearHistory(w http.ResponseWriter, r *http.Request) {
-	if err := s.historyStore.Clear(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+// isTrustedProxy reports whether ip belongs to a configured trusted proxy
+func (s *Server) isTrustedProxy(ip net.IP) bool {
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"message": "History cleared",
-	})
+	return false
 }
 
-// handleGetHistory gets a specific history entry
-func (s *Server) handleGetHistory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+// extractClientIP returns the IP of the client that sent the request.
+// X-Real-IP and then the first public address in X-Forwarded-For are only
+// used when the request comes from a trusted proxy; otherwise they could be
+// spoofed by any client.
+func (s *Server) extractClientIP(r *http.Request) string {
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = host
+	}
 
-	history, err := s.historyStore.Get(id)
+	ip := net.ParseIP(remoteIP)
+	if ip == nil || !s.isTrustedProxy(ip) {
+		return remoteIP
+	}
+
+	if realIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); realIP != nil {
+		return realIP.String()
+	}
+
+	for _, entry := range strings.Split(r.Header.Get("X-Forwarded-For"), ",") {
+		forwarded := net.ParseIP(strings.TrimSpace(entry))
+		if forwarded != nil && !forwarded.IsPrivate() && !forwarded.IsLoopback() {
+			return forwarded.String()
+		}
+	}
+
+	return remoteIP
+}
+
+// rcloneWarnings returns problems with the installed rclone version
+func (s *Server) rcloneWarnings() []string {
+	warnings := []string{}
+	if s.rcloneVersionErr != nil {
+		warnings = append(warnings, fmt.Sprintf("rclone not available: %v", s.rcloneVersionErr))
+	} else if err := rclone.CheckRcloneVersion(s.rcloneVersion, constants.MinRcloneVersion); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	return warnings
+}
+
+// handleHealth reports server health
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":         "ok",
+		"version":        Version,
+		"rclone_version": s.rcloneVersion,
+		"warnings":       s.rcloneWarnings(),
+	})
+}
+
+// handleVersion returns build metadata of the server
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"version":        Version,
+		"commit":         Commit,
+		"build_time":     BuildTime,
+		"rclone_version": s.rcloneVersion,
+		"go_version":     runtime.Version(),
+	})
+}
+
+// handleSystemMemory reports memory usage of the server process
+func (s *Server) handleSystemMemory(w http.ResponseWriter, r *http.Request) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"alloc_mb":       stats.Alloc / 1024 / 1024,
+		"total_alloc_mb": stats.TotalAlloc / 1024 / 1024,
+		"heap_objects":   stats.HeapObjects,
+		"goroutines":     runtime.NumGoroutine(),
+	})
+}
+
+// handleRcloneVersion returns the installed rclone version and its compatibility
+func (s *Server) handleRcloneVersion(w http.ResponseWriter, r *http.Request) {
+	path, _ := exec.LookPath("rclone")
+	installed := s.rcloneVersionErr == nil
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"installed":        installed,
+		"version":          s.rcloneVersion,
+		"path":             path,
+		"minimum_required": "v" + constants.MinRcloneVersion,
+		"compatible":       installed && len(s.rcloneWarnings()) == 0,
+	})
+}
+
+// handleListRemotes returns all configured remotes
+func (s *Server) handleListRemotes(w http.ResponseWriter, r *http.Request) {
+	remotes, err := s.configManager.ListRemotes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"remotes": remotes,
+	})
+}
+
+// handleAddRemote adds or updates a remote
+func (s *Server) handleAddRemote(w http.ResponseWriter, r *http.Request) {
+	var remote rclone.Remote
+	if err := json.NewDecoder(r.Body).Decode(&remote); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := remote.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conflict, err := s.remoteNameConflicts(remote.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if conflict {
+		http.Error(w, fmt.Sprintf("A remote named %s already exists with different case", remote.Name), http.StatusConflict)
+		return
+	}
+
+	if err := s.configManager.AddRemote(remote); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Remote %s configured successfully", remote.Name),
+	})
+}
+
+// remoteNameConflicts reports whether name collides case-insensitively with
+// an existing remote of a different spelling. Exact matches are updates.
+func (s *Server) remoteNameConflicts(name string) (bool, error) {
+	exists, err := s.configManager.RemoteExists(name)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	if _, err := s.configManager.GetRemote(name); err != nil {
+		if errors.Is(err, rclone.ErrRemoteNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return false, nil
+}
+
+// handleGetRemote returns a single remote
+func (s *Server) handleGetRemote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	remote, err := s.configManager.GetRemote(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(remote)
+}
+
+// handlePutRemote updates a remote in place
+func (s *Server) handlePutRemote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var remote rclone.Remote
+	if err := json.NewDecoder(r.Body).Decode(&remote); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The path decides which remote is updated; renaming via PUT is not allowed
+	remote.Name = vars["name"]
+
+	if err := remote.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conflict, err := s.remoteNameConflicts(remote.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if conflict {
+		http.Error(w, fmt.Sprintf("A remote named %s already exists with different case", remote.Name), http.StatusConflict)
+		return
+	}
+
+	if err := s.configManager.AddRemote(remote); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	updated, err := s.configManager.GetRemote(remote.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// handleDeleteRemote deletes a remote
+func (s *Server) handleDeleteRemote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	if err := s.configManager.DeleteRemote(name); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Remote %s deleted", name),
+	})
+}
+
+// handleRenameRemote renames a remote
+func (s *Server) handleRenameRemote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	var req struct {
+		NewName string `json:"new_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.configManager.RenameRemote(name, req.NewName); err != nil {
+		switch {
+		case errors.Is(err, rclone.ErrRemoteNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, rclone.ErrRemoteNameConflict):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, rclone.ErrInvalidRemoteName):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Remote %s renamed to %s", name, req.NewName),
+	})
+}
+
+// handleTestRemote tests connectivity to a configured remote. The path comes
+// from the JSON body on POST or the query string on GET and defaults to the
+// root of the remote.
+func (s *Server) handleTestRemote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	remoteName := vars["name"]
+
+	var req struct {
+		Path string `json:"path"`
+	}
+
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.Path = r.URL.Query().Get("path")
+	}
+
+	if _, err := s.configManager.GetRemote(remoteName); err != nil {
+		if errors.Is(err, rclone.ErrRemoteNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	result := s.executor.TestRemote(ctx, remoteName, req.Path)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleTestAllRemotes tests every configured remote. Results are reused for
+// a few minutes unless ?force=true is given.
+func (s *Server) handleTestAllRemotes(w http.ResponseWriter, r *http.Request) {
+	force := r.URL.Query().Get("force") == "true"
+
+	report, err := s.refreshRemoteHealth(r.Context(), force)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// refreshRemoteHealth returns the cached remote health report, testing all
+// remotes when it is missing, expired or force is set. Refreshes run one at
+// a time: a caller that waited for another refresh reuses its results when
+// they were completed after the caller arrived.
+func (s *Server) refreshRemoteHealth(ctx context.Context, force bool) (*rclone.HealthReport, error) {
+	requestedAt := time.Now()
+
+	s.remoteHealthRefreshMux.Lock()
+	defer s.remoteHealthRefreshMux.Unlock()
+
+	s.remoteHealthMux.RLock()
+	report := s.remoteHealth
+	s.remoteHealthMux.RUnlock()
+
+	if report != nil && (report.TestedAt.After(requestedAt) ||
+		(!force && time.Since(report.TestedAt) <= constants.RemoteHealthCacheTTL)) {
+		return report, nil
+	}
+
+	remotes, err := s.configManager.ListRemotes()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(remotes))
+	for _, remote := range remotes {
+		names = append(names, remote.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.RemoteTestAllTimeout)
+	defer cancel()
+
+	report = s.executor.TestRemotes(ctx, names, constants.RemoteTestConcurrency)
+
+	s.remoteHealthMux.Lock()
+	s.remoteHealth = report
+	s.remoteHealthMux.Unlock()
+
+	return report, nil
+}
+
+// handleRemotesHealth returns the last results of testing all remotes
+// without running any new test
+func (s *Server) handleRemotesHealth(w http.ResponseWriter, r *http.Request) {
+	s.remoteHealthMux.RLock()
+	report := s.remoteHealth
+	s.remoteHealthMux.RUnlock()
+
+	if report == nil {
+		http.Error(w, "No remote test results yet, run POST /api/remotes/test-all first", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleListPath lists files in a remote path
+func (s *Server) handleListPath(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	remoteName := vars["name"]
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		path = "" // Root
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	items, err := s.executor.ListPath(ctx, remoteName, path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": items,
+	})
+}
+
+// handleBrowseRich lists a remote path with modification times and MIME types,
+// sorted by ?sort=name|size|mod_time and ?order=asc|desc
+func (s *Server) handleBrowseRich(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	remoteName := vars["name"]
+	query := r.URL.Query()
+	path := query.Get("path")
+
+	order := query.Get("order")
+	if order != "" && order != "asc" && order != "desc" {
+		http.Error(w, "order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	items, err := s.executor.ListPathRich(ctx, remoteName, path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := rclone.SortFileItems(items, query.Get("sort"), order == "desc"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": items,
+	})
+}
+
+// handleRemoteKeyInfo describes the SSH key configured for a remote
+func (s *Server) handleRemoteKeyInfo(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	remote, err := s.configManager.GetRemote(name)
+	if err != nil {
+		if errors.Is(err, rclone.ErrRemoteNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if remote.KeyFile == "" {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"has_key": false,
+		})
+		return
+	}
+
+	keySource := "file"
+	if remote.KeyFile == s.configManager.GeneratedKeyPath(name) {
+		keySource = "inline"
+	}
+
+	info := map[string]interface{}{
+		"has_key":    true,
+		"key_source": keySource,
+	}
+
+	keyData, err := os.ReadFile(remote.KeyFile)
+	if err != nil {
+		info["error"] = fmt.Sprintf("failed to read key file: %v", err)
+		json.NewEncoder(w).Encode(info)
+		return
+	}
+
+	keyType, err := sshutil.ValidateSSHKey(string(keyData))
+	if err != nil {
+		info["error"] = err.Error()
+		json.NewEncoder(w).Encode(info)
+		return
+	}
+
+	signer, err := sshutil.ParseSSHKey(string(keyData))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	info["key_type"] = keyType
+	info["fingerprint"] = ssh.FingerprintSHA256(signer.PublicKey())
+	json.NewEncoder(w).Encode(info)
+}
+
+// handleUploadFile uploads a multipart "file" to ?path= on a remote. A path
+// ending with "/" is a directory and the file keeps its name.
+func (s *Server) handleUploadFile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	remoteName := vars["name"]
+	remotePath := r.URL.Query().Get("path")
+
+	if remotePath == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.configManager.GetRemote(remoteName); err != nil {
+		if errors.Is(err, rclone.ErrRemoteNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if strings.HasSuffix(remotePath, "/") {
+		remotePath = path.Join(remotePath, path.Base(header.Filename))
+	}
+
+	tmpFile, err := os.CreateTemp("", "website-mover-upload-*")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmpFile.Name())
+
+	size, err := io.Copy(tmpFile, file)
+	tmpFile.Close()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.executor.UploadFile(r.Context(), tmpFile.Name(), remoteName, remotePath); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"path":    remotePath,
+		"size":    size,
+	})
+}
+
+// handlePreviewFile returns the beginning of a file on a remote. The path is
+// encoded with URL-safe base64 so it fits in a single URL segment.
+func (s *Server) handlePreviewFile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	remoteName := vars["name"]
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(vars["path"], "="))
+	if err != nil {
+		http.Error(w, "path must be URL-safe base64", http.StatusBadRequest)
+		return
+	}
+	filePath := string(decoded)
+
+	if _, err := s.configManager.GetRemote(remoteName); err != nil {
+		if errors.Is(err, rclone.ErrRemoteNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), constants.DefaultOperationTimeout)
+	defer cancel()
+
+	preview, err := s.executor.PreviewFile(ctx, remoteName, filePath, s.config.MaxPreviewBytes, s.config.PreviewAllowedMimeTypes)
+	if err != nil {
+		switch {
+		case errors.Is(err, rclone.ErrNotAFile):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, rclone.ErrPreviewNotAllowed):
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// handleImportConfig imports remotes from an existing rclone.conf, given either
+// as a server-side path in a JSON body or as a multipart file upload
+func (s *Server) handleImportConfig(w http.ResponseWriter, r *http.Request) {
+	var configPath string
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		tmpFile, err := os.CreateTemp("", "rclone-import-*.conf")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(tmpFile.Name())
+
+		_, err = io.Copy(tmpFile, file)
+		tmpFile.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		configPath = tmpFile.Name()
+	} else {
+		var req struct {
+			ConfigPath string `json:"config_path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.ConfigPath == "" {
+			http.Error(w, "config_path is required", http.StatusBadRequest)
+			return
+		}
+		configPath = req.ConfigPath
+	}
+
+	imported, err := s.configManager.ImportFrom(configPath)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var importErr *rclone.ImportError
+	if errors.As(err, &importErr) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"imported": 0,
+			"errors":   importErr.Errors,
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"imported": imported,
+		"errors":   []string{},
+	})
+}
+
+// handleExportConfig returns the current rclone config as a file download.
+// Passwords are stored obscured in the config, so they are exported as is.
+func (s *Server) handleExportConfig(w http.ResponseWriter, r *http.Request) {
+	data, err := os.ReadFile(s.configManager.GetConfigPath())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Disposition", "attachment; filename=rclone.conf")
+	w.Write(data)
+}
+
+// handleBackupConfig creates a backup of the rclone config
+func (s *Server) handleBackupConfig(w http.ResponseWriter, r *http.Request) {
+	if err := s.configManager.BackupConfig(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Config backed up",
+	})
+}
+
+// handleRestoreConfig restores the rclone config from the latest backup
+func (s *Server) handleRestoreConfig(w http.ResponseWriter, r *http.Request) {
+	if err := s.configManager.RestoreLatestBackup(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Config restored from latest backup",
+	})
+}
+
+// handleValidateSSHKey validates a private key and reports its type and fingerprint
+func (s *Server) handleValidateSSHKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SSHKey string `json:"ssh_key"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	keyType, err := sshutil.ValidateSSHKey(req.SSHKey)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"valid": false,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	signer, err := sshutil.ParseSSHKey(req.SSHKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"valid":       true,
+		"key_type":    keyType,
+		"fingerprint": ssh.FingerprintSHA256(signer.PublicKey()),
+	})
+}
+
+// handleDiagnose runs step by step connection diagnostics against an SSH/SFTP server
+func (s *Server) handleDiagnose(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Host     string `json:"host"`
+		Port     int    `json:"port"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		SSHKey   string `json:"ssh_key"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Host == "" {
+		http.Error(w, "host is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), constants.DefaultOperationTimeout)
+	defer cancel()
+
+	result := sshutil.Diagnose(ctx, sshutil.ConnectionConfig{
+		Host:     req.Host,
+		Port:     req.Port,
+		Username: req.Username,
+		Password: req.Password,
+		SSHKey:   req.SSHKey,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleCheckSSL reports the certificate served by ?host= on ?port= (default 443)
+func (s *Server) handleCheckSSL(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Query().Get("host")
+	if host == "" {
+		http.Error(w, "host is required", http.StatusBadRequest)
+		return
+	}
+
+	port := 443
+	if value := r.URL.Query().Get("port"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 65535 {
+			http.Error(w, "invalid port", http.StatusBadRequest)
+			return
+		}
+		port = parsed
+	}
+
+	info, err := tlsutil.CheckSSLCertificate(host, port)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	warnings := []string{}
+	switch {
+	case info.IsExpired:
+		warnings = append(warnings, fmt.Sprintf("certificate expired on %s", info.NotAfter.Format("2006-01-02")))
+	case info.ExpiringSoon():
+		warnings = append(warnings, fmt.Sprintf("certificate expires in %d days, renew it before migrating", info.DaysUntilExpiry))
+	}
+	if info.IsSelfSigned {
+		warnings = append(warnings, "certificate is self-signed")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"host":     host,
+		"port":     port,
+		"ssl":      info,
+		"warnings": warnings,
+	})
+}
+
+// handleImportGitignore converts an uploaded .gitignore file ("file" form
+// field) to exclude patterns for a migration
+func (s *Server) handleImportGitignore(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	excludes, skipped := rclone.GitignoreToExcludes(string(content))
+	if excludes == nil {
+		excludes = []string{}
+	}
+	if skipped == nil {
+		skipped = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"excludes": excludes,
+		"skipped":  skipped,
+	})
+}
+
+// handleTestEmail sends a test email with the given SMTP settings
+func (s *Server) handleTestEmail(w http.ResponseWriter, r *http.Request) {
+	var cfg rclone.EmailConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validation.ValidateEmailConfig(cfg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	body := "This is a test email from Website Mover. Migration notifications will be sent to this address."
+	if err := notify.SendEmail(cfg, "[Website Mover] Test email", body); err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Test email sent to %s", cfg.To),
+	})
+}
+
+// handleStartMigration starts a new migration
+func (s *Server) handleStartMigration(w http.ResponseWriter, r *http.Request) {
+	var opts rclone.MigrationOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(opts.DestRemotes) > 1 {
+		s.startBatchMigration(w, r, opts)
+		return
+	}
+	if len(opts.DestRemotes) == 1 {
+		opts = opts.ForDestination(opts.DestRemotes[0])
+	}
+
+	if err := validation.ValidateMigrationOptions(opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.launchMigration(r, opts, jobLinks{})
+	if err != nil {
+		writeLaunchError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job_id":     job.ID,
+		"command":    job.Command,
+		"status":     job.GetStatus(),
+		"size_check": job.SizeCheck,
+	})
+}
+
+// jobLinks ties a new migration to the history entry it replays or retries
+// and to the batch it is part of
+type jobLinks struct {
+	replayOfID string
+	retryOfID   string
+	retryRootID string
+	retryCount  int
+	batchID     string
+}
+
+// launchMigration applies option defaults, starts the migration and tracks it
+// until it is recorded in history
+func (s *Server) launchMigration(r *http.Request, opts rclone.MigrationOptions, links jobLinks) (*rclone.MigrationJob, error) {
+	// Set defaults
+	if opts.Transfers == 0 {
+		opts.Transfers = 8
+	}
+	if opts.Checkers == 0 {
+		opts.Checkers = 8
+	}
+
+	// Measure the source when the migration has size limits. Enforced limits
+	// refuse the migration, the others are only reported on the job.
+	var sizeCheck *rclone.LimitCheck
+	if opts.HasSizeLimits() {
+		sizeCtx, cancel := context.WithTimeout(r.Context(), constants.SizeCheckTimeout)
+		size, err := s.executor.MigrationSize(sizeCtx, opts)
+		cancel()
+		if err != nil {
+			if opts.MaxFilesCheck || opts.MaxBytesCheck {
+				return nil, fmt.Errorf("failed to check migration size: %w", err)
+			}
+			logRequest(r, "WARNING: Failed to check migration size, starting anyway: %v", err)
+		} else if sizeCheck, err = rclone.CheckLimits(opts, size); err != nil {
+			return nil, err
+		}
+	}
+
+	// Use background context so migration continues after HTTP response
+	job, err := s.executor.StartMigration(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	job.SizeCheck = sizeCheck
+	job.ReplayOfID = links.replayOfID
+	job.RetryOfID = links.retryOfID
+	job.RetryRootID = links.retryRootID
+	job.RetryCount = links.retryCount
+	job.BatchID = links.batchID
+	job.ClientIP = s.extractClientIP(r)
+	job.UserAgent = r.Header.Get("User-Agent")
+	job.RequestID = util.GetRequestID(r.Context())
+
+	// Track job
+	s.jobsMux.Lock()
+	s.activeJobs[job.ID] = job
+	s.jobsMux.Unlock()
+
+	// Monitor job completion
+	go func() {
+		<-job.Done()
+		
+		// Add to history and remove from active jobs
+		s.finalizeJob(job)
+	}()
+
+	return job, nil
+}
+
+// handleStartBatchMigration starts one migration per destination of dest_remotes
+func (s *Server) handleStartBatchMigration(w http.ResponseWriter, r *http.Request) {
+	var opts rclone.MigrationOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.startBatchMigration(w, r, opts)
+}
+
+// writeLaunchError reports an error from launchMigration: 422 with the
+// measured size when an enforced limit is exceeded, 500 otherwise
+func writeLaunchError(w http.ResponseWriter, err error) {
+	var limitErr *rclone.LimitExceededError
+	if !errors.As(err, &limitErr) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":        "would_exceed_limit",
+		"message":      err.Error(),
+		"actual_files": limitErr.Check.ActualFiles,
+		"max_files":    limitErr.Check.MaxFiles,
+		"actual_bytes": limitErr.Check.ActualBytes,
+		"max_bytes":    limitErr.Check.MaxBytes,
+	})
+}
+
+// startBatchMigration launches the migrations of a batch and records the batch
+func (s *Server) startBatchMigration(w http.ResponseWriter, r *http.Request, opts rclone.MigrationOptions) {
+	if err := validation.ValidateBatchMigrationOptions(opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	batch := &rclone.BatchJob{
+		MasterID:  util.GenerateJobID(constants.BatchJobIDPrefix),
+		SubJobIDs: []string{},
+		StartTime: time.Now(),
+	}
+
+	jobs := []map[string]interface{}{}
+	started := []*rclone.MigrationJob{}
+	for _, dest := range opts.DestRemotes {
+		job, err := s.launchMigration(r, opts.ForDestination(dest), jobLinks{batchID: batch.MasterID})
+		if err != nil {
+			// Don't leave part of the batch running
+			for _, job := range started {
+				job.Cancel()
+			}
+			writeLaunchError(w, fmt.Errorf("failed to start migration to %s:%s: %w", dest.Remote, dest.Path, err))
+			return
+		}
+
+		started = append(started, job)
+		batch.SubJobIDs = append(batch.SubJobIDs, job.ID)
+		jobs = append(jobs, map[string]interface{}{
+			"job_id":      job.ID,
+			"command":     job.Command,
+			"dest_remote": dest.Remote,
+			"dest_path":   dest.Path,
+		})
+	}
+
+	s.batchMux.Lock()
+	s.batchJobs[batch.MasterID] = batch
+	s.batchMux.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"batch_id":    batch.MasterID,
+		"sub_job_ids": batch.SubJobIDs,
+		"jobs":        jobs,
+		"status":      "running",
+	})
+}
+
+// handleGetBatchMigration returns the aggregate status of a batch migration
+// and the status of each of its migrations
+func (s *Server) handleGetBatchMigration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	s.batchMux.RLock()
+	batch, exists := s.batchJobs[id]
+	s.batchMux.RUnlock()
+	if !exists {
+		http.Error(w, "Batch not found", http.StatusNotFound)
+		return
+	}
+
+	jobs := []map[string]interface{}{}
+	statuses := []string{}
+
+	s.jobsMux.RLock()
+	for _, jobID := range batch.SubJobIDs {
+		entry := map[string]interface{}{"job_id": jobID}
+
+		if job, active := s.activeJobs[jobID]; active {
+			stats := job.GetStats()
+			entry["status"] = job.GetStatus()
+			entry["dest_remote"] = job.Options.DestRemote
+			entry["dest_path"] = job.Options.DestPath
+			entry["total_bytes"] = stats.TotalBytes
+			entry["total_files"] = stats.TotalFiles
+		} else if history, err := s.historyStore.Get(jobID); err == nil {
+			entry["status"] = history.Status
+			entry["dest_remote"] = history.Options.DestRemote
+			entry["dest_path"] = history.Options.DestPath
+			entry["total_bytes"] = history.TotalBytes
+			entry["total_files"] = history.TotalFiles
+		} else {
+			// Dropped from the history
+			entry["status"] = "unknown"
+		}
+
+		statuses = append(statuses, entry["status"].(string))
+		jobs = append(jobs, entry)
+	}
+	s.jobsMux.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"batch_id":    batch.MasterID,
+		"sub_job_ids": batch.SubJobIDs,
+		"start_time":  batch.StartTime,
+		"status":      rclone.BatchStatus(statuses),
+		"jobs":        jobs,
+	})
+}
+
+// handleStartIncrementalMigration starts a migration that only copies files
+// modified since the last successful migration between the same paths
+func (s *Server) handleStartIncrementalMigration(w http.ResponseWriter, r *http.Request) {
+	var opts rclone.MigrationOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validation.ValidateMigrationOptions(opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Without a previous successful run everything is copied
+	opts.IncrementalSince = nil
+	last, err := s.historyStore.LastSuccessful(opts.SourceRemote, opts.SourcePath, opts.DestRemote, opts.DestPath)
+	if err == nil {
+		since := last.EndTime
+		opts.IncrementalSince = &since
+	} else if !errors.Is(err, os.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	countCtx, cancel := context.WithTimeout(r.Context(), constants.DefaultOperationTimeout)
+	estimatedFiles, err := s.executor.CountFiles(countCtx, opts.SourceRemote, opts.SourcePath, opts.IncrementalSince)
+	cancel()
+	if err != nil {
+		logRequest(r, "Failed to estimate incremental migration size: %v", err)
+		estimatedFiles = -1
+	}
+
+	job, err := s.launchMigration(r, opts, jobLinks{})
+	if err != nil {
+		writeLaunchError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job_id":            job.ID,
+		"command":           job.Command,
+		"status":            job.GetStatus(),
+		"incremental_since": opts.IncrementalSince,
+		"estimated_files":   estimatedFiles,
+		"size_check":        job.SizeCheck,
+	})
+}
+
+// handleCancelMigration cancels a running migration
+func (s *Server) handleCancelMigration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	s.jobsMux.RLock()
+	job, exists := s.activeJobs[jobID]
+	s.jobsMux.RUnlock()
+
+	if !exists {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	job.Cancel()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Cancellation requested for %s", jobID),
+	})
+}
+
+// handleGetMigrationStats returns a snapshot of a migration's stats
+func (s *Server) handleGetMigrationStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	s.jobsMux.RLock()
+	job, exists := s.activeJobs[jobID]
+	s.jobsMux.RUnlock()
+
+	var stats rclone.JobStats
+	if exists {
+		stats = job.GetStats()
+	} else {
+		// Finished jobs only keep the summary stats in history
+		history, err := s.historyStore.Get(jobID)
+		if err != nil {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		stats = rclone.JobStats{
+			TotalBytes:    history.TotalBytes,
+			TotalFiles:    history.TotalFiles,
+			TransferSpeed: history.TransferSpeed,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// handleStreamMigration streams migration output via SSE
+func (s *Server) handleStreamMigration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["id"]
+
+	// Find job
+	s.jobsMux.RLock()
+	job, exists := s.activeJobs[jobID]
+	s.jobsMux.RUnlock()
+
+	if !exists {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	// Setup SSE
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// SSE comment linking the stream to the request that started the job
+	fmt.Fprintf(w, ": request_id=%s job_request_id=%s\n\n", util.GetRequestID(r.Context()), job.RequestID)
+	flusher.Flush()
+
+	// Subscribe to job output
+	ch := job.Subscribe()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				// Channel closed, job completed
+				fmt.Fprintf(w, "data: {\"type\":\"complete\",\"status\":\"%s\"}\n\n", job.GetStatus())
+				flusher.Flush()
+				return
+			}
+			
+			// Send event
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+// findDryRunReport looks up the dry-run report of an active or completed job
+func (s *Server) findDryRunReport(id string) (*rclone.DryRunReport, error) {
+	s.jobsMux.RLock()
+	job, exists := s.activeJobs[id]
+	s.jobsMux.RUnlock()
+
+	if exists {
+		report := job.GetDryRunReport()
+		if report == nil {
+			return nil, fmt.Errorf("dry-run report not available yet")
+		}
+		return report, nil
+	}
+
+	history, err := s.historyStore.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("migration not found")
+	}
+	if history.DryRunReport == nil {
+		return nil, fmt.Errorf("migration %s was not a dry run", id)
+	}
+
+	return history.DryRunReport, nil
+}
+
+// handleGetDryRunReport returns the structured dry-run report as JSON
+func (s *Server) handleGetDryRunReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	report, err := s.findDryRunReport(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleGetDryRunReportCSV returns the dry-run report as a CSV download
+func (s *Server) handleGetDryRunReportCSV(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	report, err := s.findDryRunReport(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=dry-run-%s.csv", id))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"filename", "size", "action"})
+	for _, item := range report.WouldTransfer {
+		writer.Write([]string{item.Name, fmt.Sprintf("%d", item.Size), "transfer"})
+	}
+	for _, item := range report.WouldUpdate {
+		writer.Write([]string{item.Name, fmt.Sprintf("%d", item.Size), "update"})
+	}
+	for _, item := range report.WouldDelete {
+		writer.Write([]string{item.Name, fmt.Sprintf("%d", item.Size), "delete"})
+	}
+	writer.Flush()
+}
+
+// handleGetDryRunDiff returns the dry-run report as unified diff style text,
+// with the change counts in X-Diff-* headers
+func (s *Server) handleGetDryRunDiff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	report, err := s.findDryRunReport(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	summary, changes := report.Diff()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Diff-Added", strconv.Itoa(summary.Added))
+	w.Header().Set("X-Diff-Updated", strconv.Itoa(summary.Updated))
+	w.Header().Set("X-Diff-Deleted", strconv.Itoa(summary.Deleted))
+	w.Header().Set("X-Diff-Added-Bytes", strconv.FormatInt(summary.TotalAddedBytes, 10))
+	w.Header().Set("X-Diff-Deleted-Bytes", strconv.FormatInt(summary.TotalDeletedBytes, 10))
+	io.WriteString(w, rclone.FormatDiff(changes))
+}
+
+// handleGetDryRunDiffJSON returns the dry-run changes and their summary as JSON
+func (s *Server) handleGetDryRunDiffJSON(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	report, err := s.findDryRunReport(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	summary, changes := report.Diff()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"summary": summary,
+		"changes": changes,
+	})
+}
+
+// handleVerifyMigration starts an asynchronous rclone check between the
+// source and destination of a finished migration
+func (s *Server) handleVerifyMigration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	s.jobsMux.RLock()
+	_, running := s.activeJobs[id]
+	s.jobsMux.RUnlock()
+	if running {
+		http.Error(w, "Migration is still running", http.StatusConflict)
+		return
+	}
+
+	history, err := s.historyStore.Get(id)
+	if err != nil {
+		http.Error(w, "Migration not found", http.StatusNotFound)
+		return
+	}
+
+	verifyJob := &rclone.VerifyJob{
+		ID:          util.GenerateJobID(constants.VerifyJobIDPrefix),
+		MigrationID: id,
+		Status:      "running",
+		StartTime:   time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.VerifyTimeout)
+
+	s.verifyMux.Lock()
+	s.pruneVerifyJobs(time.Now())
+	s.verifyJobs[verifyJob.ID] = verifyJob
+	s.verifyCancels[verifyJob.ID] = cancel
+	s.verifyMux.Unlock()
+
+	if err := s.historyStore.SetVerification(id, verifyJob.ID, nil); err != nil {
+		logRequest(r, "Failed to record verification %s in history: %v", verifyJob.ID, err)
+	}
+
+	s.verifyWG.Add(1)
+	go func() {
+		defer s.verifyWG.Done()
+		defer cancel()
+		result, err := s.executor.RunCheck(ctx, history.Options)
+
+		s.verifyMux.Lock()
+		delete(s.verifyCancels, verifyJob.ID)
+		now := time.Now()
+		verifyJob.EndTime = &now
+		if err != nil {
+			verifyJob.Status = "failed"
+			verifyJob.Error = err.Error()
+			switch ctx.Err() {
+			case context.Canceled:
+				verifyJob.Status = "cancelled"
+				verifyJob.Error = "verification cancelled by server shutdown"
+			case context.DeadlineExceeded:
+				verifyJob.Error = fmt.Sprintf("verification timed out after %s", constants.VerifyTimeout)
+			}
+		} else {
+			verifyJob.Status = "completed"
+			verifyJob.Result = result
+		}
+		s.verifyMux.Unlock()
+
+		if err == nil {
+			if err := s.historyStore.SetVerification(id, verifyJob.ID, result); err != nil {
+				logRequest(r, "Failed to record verification %s in history: %v", verifyJob.ID, err)
+			}
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"verify_job_id": verifyJob.ID,
+		"status":        verifyJob.Status,
+	})
+}
+
+// pruneVerifyJobs forgets verifications that finished more than
+// VerifyJobRetention before now; verifyMux must be held
+func (s *Server) pruneVerifyJobs(now time.Time) {
+	for id, verifyJob := range s.verifyJobs {
+		if verifyJob.EndTime != nil && now.Sub(*verifyJob.EndTime) > constants.VerifyJobRetention {
+			delete(s.verifyJobs, id)
+		}
+	}
+}
+
+// cancelVerifications stops the running verifications and waits until they
+// have recorded their final status
+func (s *Server) cancelVerifications() {
+	s.verifyMux.Lock()
+	for _, cancel := range s.verifyCancels {
+		cancel()
+	}
+	s.verifyMux.Unlock()
+
+	s.verifyWG.Wait()
+}
+
+// handleGetVerification returns the status and result of a verification job
+func (s *Server) handleGetVerification(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	s.verifyMux.RLock()
+	defer s.verifyMux.RUnlock()
+
+	verifyJob, exists := s.verifyJobs[id]
+	if !exists {
+		http.Error(w, "Verification not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(verifyJob)
+}
+
+// handleListActiveJobs lists currently running jobs
+func (s *Server) handleListActiveJobs(w http.ResponseWriter, r *http.Request) {
+	s.jobsMux.RLock()
+	defer s.jobsMux.RUnlock()
+
+	jobs := []map[string]interface{}{}
+	for _, job := range s.activeJobs {
+		jobs = append(jobs, map[string]interface{}{
+			"id":         job.ID,
+			"command":    job.Command,
+			"start_time": job.StartTime,
+			"status":     job.GetStatus(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs": jobs,
+	})
+}
+
+// handleListMigrations lists all migrations (active + history)
+func (s *Server) handleListMigrations(w http.ResponseWriter, r *http.Request) {
+	// Get history
+	history, err := s.historyStore.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Get active jobs
+	s.jobsMux.RLock()
+	activeJobs := make([]map[string]interface{}, 0, len(s.activeJobs))
+	for _, job := range s.activeJobs {
+		activeJobs = append(activeJobs, map[string]interface{}{
+			"id":         job.ID,
+			"command":    job.Command,
+			"start_time": job.StartTime,
+			"status":     job.GetStatus(),
+			"options":    job.Options.WithoutSecrets(),
+		})
+	}
+	s.jobsMux.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"active":  activeJobs,
+		"history": history,
+	})
+}
+
+// handleListHistory lists migration history
+func (s *Server) handleListHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := s.historyStore.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"history": history,
+	})
+}
+
+// handleClearHistory clears all migration history
+func (s *Server) handleClearHistory(w http.ResponseWriter, r *http.Request) {
+	// Require explicit confirmation to prevent accidental wipes
+	if r.URL.Query().Get("confirm") != "true" {
+		http.Error(w, "Clearing history requires confirm=true", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.historyStore.Clear(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "History cleared",
+	})
+}
+
+// handleGetHistory gets a specific history entry
+func (s *Server) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	history, err := s.historyStore.Get(id)
 	if err != nil {
 		http.Error(w, "History not found", http.StatusNotFound)
 		return
@@ -379,3 +2101,461 @@ func (s *Server) handleGetHistory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(history)
 }
+
+// missingRemotes returns the remotes used by a migration that are no longer configured
+func (s *Server) missingRemotes(opts rclone.MigrationOptions) ([]string, error) {
+	missing := []string{}
+	for _, name := range []string{opts.SourceRemote, opts.DestRemote} {
+		if _, err := s.configManager.GetRemote(name); err != nil {
+			if !errors.Is(err, rclone.ErrRemoteNotFound) {
+				return nil, err
+			}
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// handleReplayFeasibility reports whether a history entry can be replayed
+func (s *Server) handleReplayFeasibility(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	history, err := s.historyStore.Get(id)
+	if err != nil {
+		http.Error(w, "History not found", http.StatusNotFound)
+		return
+	}
+
+	missing, err := s.missingRemotes(history.Options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"feasible":        len(missing) == 0,
+		"missing_remotes": missing,
+	})
+}
+
+// restoreEmailPassword puts back the SMTP password of the notification
+// email, which history entries do not store. When the server needs a login
+// and no password is given, the notification is dropped instead of failing
+// to authenticate after the migration, and a warning is returned.
+func restoreEmailPassword(opts *rclone.MigrationOptions, password string) []string {
+	cfg := opts.EmailConfig
+	if cfg == nil || cfg.To == "" || cfg.Username == "" || cfg.Password != "" {
+		return []string{}
+	}
+
+	if password == "" {
+		opts.EmailConfig = nil
+		return []string{"Email notification disabled: the SMTP password is not kept in history, send email_password to keep it"}
+	}
+
+	email := *cfg
+	email.Password = password
+	opts.EmailConfig = &email
+	return []string{}
+}
+
+// handleReplayHistory starts a new migration with the options of a history
+// entry. The optional JSON body carries the SMTP password of the notification
+// email, which is not kept in history.
+func (s *Server) handleReplayHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		EmailPassword string `json:"email_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := s.historyStore.Get(id)
+	if err != nil {
+		http.Error(w, "History not found", http.StatusNotFound)
+		return
+	}
+
+	missing, err := s.missingRemotes(history.Options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(missing) > 0 {
+		http.Error(w, fmt.Sprintf("Cannot replay migration: remote(s) %s no longer exist. Recreate them or start a new migration", strings.Join(missing, ", ")), http.StatusUnprocessableEntity)
+		return
+	}
+
+	opts := history.Options
+	warnings := restoreEmailPassword(&opts, req.EmailPassword)
+
+	if err := validation.ValidateMigrationOptions(opts); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	job, err := s.launchMigration(r, opts, jobLinks{replayOfID: history.ID})
+	if err != nil {
+		writeLaunchError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job_id":       job.ID,
+		"command":      job.Command,
+		"status":       job.GetStatus(),
+		"replay_of_id": history.ID,
+		"size_check":   job.SizeCheck,
+		"warnings":     warnings,
+	})
+}
+
+// handleRetryMigration starts a failed migration again with the same options.
+// When the failed run transferred part of the files, the retry passes
+// --ignore-errors so that the files that fail again do not block the rest.
+// Like a replay, it takes the SMTP password of the notification email in an
+// optional JSON body.
+func (s *Server) handleRetryMigration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		EmailPassword string `json:"email_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.jobsMux.RLock()
+	_, running := s.activeJobs[id]
+	s.jobsMux.RUnlock()
+	if running {
+		http.Error(w, "Migration is still running", http.StatusConflict)
+		return
+	}
+
+	history, err := s.historyStore.Get(id)
+	if err != nil {
+		http.Error(w, "Migration not found", http.StatusNotFound)
+		return
+	}
+	if history.Status != "failed" {
+		http.Error(w, fmt.Sprintf("Only failed migrations can be retried, this one is %s", history.Status), http.StatusConflict)
+		return
+	}
+
+	missing, err := s.missingRemotes(history.Options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(missing) > 0 {
+		http.Error(w, fmt.Sprintf("Cannot retry migration: remote(s) %s no longer exist", strings.Join(missing, ", ")), http.StatusUnprocessableEntity)
+		return
+	}
+
+	opts := history.Options
+	warnings := restoreEmailPassword(&opts, req.EmailPassword)
+	partial := history.TotalFiles > 0 || history.TotalBytes > 0
+	if partial {
+		opts.IgnoreErrors = true
+	}
+
+	if err := validation.ValidateMigrationOptions(opts); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	rootID := history.RetryRootID
+	if rootID == "" {
+		rootID = history.ID
+	}
+
+	s.retryMux.Lock()
+	retries, err := s.countRetries(rootID)
+	if err != nil {
+		s.retryMux.Unlock()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	job, err := s.launchMigration(r, opts, jobLinks{retryOfID: history.ID, retryRootID: rootID, retryCount: retries + 1})
+	s.retryMux.Unlock()
+	if err != nil {
+		writeLaunchError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job_id":        job.ID,
+		"command":       job.Command,
+		"status":        job.GetStatus(),
+		"retry_of_id":   history.ID,
+		"retry_root_id": rootID,
+		"retry_count":   job.RetryCount,
+		"ignore_errors": opts.IgnoreErrors,
+		"warnings":      warnings,
+	})
+}
+
+// countRetries returns how many times the original migration rootID has been
+// retried, counting the retries still running. jobsMux is held so that a
+// retry finishing meanwhile is not counted both as running and in history.
+func (s *Server) countRetries(rootID string) (int, error) {
+	s.jobsMux.RLock()
+	defer s.jobsMux.RUnlock()
+
+	count, err := s.historyStore.CountRetries(rootID)
+	if err != nil {
+		return 0, err
+	}
+	for _, job := range s.activeJobs {
+		if job.RetryRootID == rootID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// handleCleanupMigration removes the partial uploads a failed or cancelled
+// migration left at its destination
+func (s *Server) handleCleanupMigration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	s.jobsMux.RLock()
+	_, running := s.activeJobs[id]
+	s.jobsMux.RUnlock()
+	if running {
+		http.Error(w, "Migration is still running", http.StatusConflict)
+		return
+	}
+
+	history, err := s.historyStore.Get(id)
+	if err != nil {
+		http.Error(w, "Migration not found", http.StatusNotFound)
+		return
+	}
+	if history.Status != "failed" && history.Status != "cancelled" {
+		http.Error(w, fmt.Sprintf("Only failed or cancelled migrations can be cleaned up, this one is %s", history.Status), http.StatusConflict)
+		return
+	}
+	if history.Options.DryRun {
+		http.Error(w, "Dry runs do not write any files", http.StatusConflict)
+		return
+	}
+
+	result := s.cleanupPartialFiles(util.GetRequestID(r.Context()), id, history.Options, history.StartTime)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleDeleteHistory deletes a specific history entry
+func (s *Server) handleDeleteHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := s.historyStore.Delete(id); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.Error(w, "History not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeHistoryCSV writes history entries as a CSV attachment
+func writeHistoryCSV(w http.ResponseWriter, filename string, entries []rclone.MigrationHistory) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	writer := csv.NewWriter(w)
+	writer.Write(rclone.HistoryCSVHeader)
+	for _, h := range entries {
+		writer.Write(h.CSVRecord())
+	}
+	writer.Flush()
+}
+
+// handleExportHistoryEntryCSV exports a single history entry as CSV
+func (s *Server) handleExportHistoryEntryCSV(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	history, err := s.historyStore.Get(id)
+	if err != nil {
+		http.Error(w, "History not found", http.StatusNotFound)
+		return
+	}
+
+	writeHistoryCSV(w, fmt.Sprintf("migration-%s.csv", id), []rclone.MigrationHistory{*history})
+}
+
+// handleExportHistoryGitignore exports the exclude patterns of a past
+// migration as a .gitignore file
+func (s *Server) handleExportHistoryGitignore(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	history, err := s.historyStore.Get(id)
+	if err != nil {
+		http.Error(w, "History not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=migration-%s.gitignore", id))
+	io.WriteString(w, rclone.ExcludesToGitignore(history.Options.Excludes))
+}
+
+// handleExportHistoryCSV exports the history as CSV, optionally filtered with
+// ?from=YYYY-MM-DD and ?to=YYYY-MM-DD (both inclusive)
+func (s *Server) handleExportHistoryCSV(w http.ResponseWriter, r *http.Request) {
+	var from, to time.Time
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			http.Error(w, "from must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			http.Error(w, "to must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	history, err := s.historyStore.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filtered := make([]rclone.MigrationHistory, 0, len(history))
+	for _, h := range history {
+		if !from.IsZero() && h.StartTime.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !h.StartTime.Before(to) {
+			continue
+		}
+		filtered = append(filtered, h)
+	}
+
+	writeHistoryCSV(w, "migration-history.csv", filtered)
+}
+
+// historyTotals aggregates a list of history entries
+type historyTotals struct {
+	count, successful, failed int
+	bytes, files              int64
+	durationSeconds           float64
+	oldest, newest            *time.Time
+	sourceCounts, destCounts  map[string]int
+}
+
+// aggregateHistory computes the totals shared by the history summary and
+// stats endpoints
+func aggregateHistory(history []rclone.MigrationHistory) historyTotals {
+	totals := historyTotals{
+		count:        len(history),
+		sourceCounts: make(map[string]int),
+		destCounts:   make(map[string]int),
+	}
+
+	for i := range history {
+		h := &history[i]
+		switch h.Status {
+		case "completed":
+			totals.successful++
+		case "failed":
+			totals.failed++
+		}
+		totals.bytes += h.TotalBytes
+		totals.files += h.TotalFiles
+		totals.durationSeconds += h.DurationSeconds()
+		totals.sourceCounts[h.Options.SourceRemote]++
+		totals.destCounts[h.Options.DestRemote]++
+
+		if totals.oldest == nil || h.StartTime.Before(*totals.oldest) {
+			totals.oldest = &h.StartTime
+		}
+		if totals.newest == nil || h.StartTime.After(*totals.newest) {
+			totals.newest = &h.StartTime
+		}
+	}
+
+	return totals
+}
+
+// handleHistorySummary returns totals and the most used remotes over the migration history
+func (s *Server) handleHistorySummary(w http.ResponseWriter, r *http.Request) {
+	history, err := s.historyStore.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	totals := aggregateHistory(history)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_migrations":        totals.count,
+		"successful":              totals.successful,
+		"failed":                  totals.failed,
+		"total_bytes_transferred": totals.bytes,
+		"total_files_transferred": totals.files,
+		"total_duration_seconds":  int64(totals.durationSeconds),
+		"most_used_source":        mostUsed(totals.sourceCounts),
+		"most_used_dest":          mostUsed(totals.destCounts),
+	})
+}
+
+// mostUsed returns the key with the highest count, preferring the
+// alphabetically first name on ties
+func mostUsed(counts map[string]int) string {
+	best, bestCount := "", 0
+	for name, count := range counts {
+		if count > bestCount || (count == bestCount && name < best) {
+			best, bestCount = name, count
+		}
+	}
+	return best
+}
+
+// handleHistoryStats returns aggregate statistics over the migration history
+func (s *Server) handleHistoryStats(w http.ResponseWriter, r *http.Request) {
+	history, err := s.historyStore.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	totals := aggregateHistory(history)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_count":             totals.count,
+		"success_count":           totals.successful,
+		"failed_count":            totals.failed,
+		"total_bytes_transferred": totals.bytes,
+		"oldest_entry":            totals.oldest,
+		"newest_entry":            totals.newest,
+	})
+}
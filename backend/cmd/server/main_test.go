@@ -0,0 +1,1069 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+	"github.com/gonzague/website-mover/backend/internal/rclone"
+	"github.com/gonzague/website-mover/backend/internal/util"
+	"github.com/gorilla/mux"
+)
+
+// writeFakeRclone puts an "rclone" shell script first in PATH that appends
+// its arguments to the returned log file, then runs script
+func writeFakeRclone(t *testing.T, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls.log")
+	content := "#!/bin/sh\necho \"$*\" >> \"" + logFile + "\"\n" + script + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "rclone"), []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logFile
+}
+
+// newTestServer returns a Server keeping its rclone config and history in
+// temporary directories
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	configManager, err := rclone.NewConfigManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	historyStore, err := rclone.NewHistoryStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Server{
+		configManager: configManager,
+		executor:      rclone.NewExecutor(""),
+		historyStore:  historyStore,
+		activeJobs:    make(map[string]*rclone.MigrationJob),
+		verifyJobs:    make(map[string]*rclone.VerifyJob),
+		verifyCancels: make(map[string]context.CancelFunc),
+		batchJobs:     make(map[string]*rclone.BatchJob),
+	}
+}
+
+func TestNeedsCleanup(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		cleanup bool
+		dryRun  bool
+		want    bool
+	}{
+		{"failed", "failed", true, false, true},
+		{"cancelled", "cancelled", true, false, true},
+		{"completed", "completed", true, false, false},
+		{"cleanup not requested", "failed", false, false, false},
+		{"dry run", "failed", true, true, false},
+	}
+
+	for _, tt := range tests {
+		job := &rclone.MigrationJob{
+			Status:  tt.status,
+			Options: rclone.MigrationOptions{CleanupOnFailure: tt.cleanup, DryRun: tt.dryRun},
+		}
+		if got := needsCleanup(job); got != tt.want {
+			t.Errorf("%s: needsCleanup = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// finishJob runs a migration with the fake rclone and finalizes it
+func finishJob(t *testing.T, s *Server, opts rclone.MigrationOptions) *rclone.MigrationJob {
+	t.Helper()
+	job, err := s.executor.StartMigration(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.jobsMux.Lock()
+	s.activeJobs[job.ID] = job
+	s.jobsMux.Unlock()
+	if !job.WaitForCompletion(10 * time.Second) {
+		t.Fatal("job did not complete")
+	}
+	s.finalizeJob(job)
+	return job
+}
+
+// cleanupOpts are the options of a migration that cleans up after failing
+var cleanupOpts = rclone.MigrationOptions{
+	SourceRemote:     "src",
+	SourcePath:       "/",
+	DestRemote:       "dst",
+	DestPath:         "/var/www",
+	CleanupOnFailure: true,
+}
+
+// waitFinalized waits until a job started by launchMigration is in history
+func waitFinalized(t *testing.T, s *Server, job *rclone.MigrationJob) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		s.jobsMux.RLock()
+		_, active := s.activeJobs[job.ID]
+		s.jobsMux.RUnlock()
+		if !active {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job was not finalized")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFinalizeJobCleansUpFailedMigration(t *testing.T) {
+	logFile := writeFakeRclone(t, `case "$1" in
+  copy) exit 1 ;;
+  size) echo '{"count":1,"bytes":42}' ;;
+esac`)
+	s := newTestServer(t)
+
+	job := finishJob(t, s, cleanupOpts)
+	if job.GetStatus() != "failed" {
+		t.Fatalf("status = %s, want failed", job.GetStatus())
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		history, err := s.historyStore.Get(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if history.Cleanup != nil {
+			if history.Cleanup.FilesDeleted != 1 || history.Cleanup.BytesFreed != 42 {
+				t.Errorf("cleanup = %+v, want 1 file and 42 bytes", history.Cleanup)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cleanup was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	calls, _ := os.ReadFile(logFile)
+	if !strings.Contains(string(calls), "delete dst:/var/www --include *.*.partial") {
+		t.Errorf("no partial file delete in rclone calls:\n%s", calls)
+	}
+}
+
+func TestFinalizeJobKeepsFilesOfSuccessfulMigration(t *testing.T) {
+	logFile := writeFakeRclone(t, "exit 0")
+	s := newTestServer(t)
+
+	job := finishJob(t, s, cleanupOpts)
+	if job.GetStatus() != "completed" {
+		t.Fatalf("status = %s, want completed", job.GetStatus())
+	}
+
+	// Cleanup runs in the background; give it a chance to show up
+	time.Sleep(200 * time.Millisecond)
+
+	calls, _ := os.ReadFile(logFile)
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "copy ") {
+		t.Errorf("rclone calls = %q, want only the copy", lines)
+	}
+	history, err := s.historyStore.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.Cleanup != nil {
+		t.Errorf("cleanup = %+v, want none", history.Cleanup)
+	}
+}
+
+func TestLaunchMigrationSizeLimits(t *testing.T) {
+	writeFakeRclone(t, `case "$1" in
+  size) echo '{"count":500,"bytes":1000}' ;;
+esac`)
+
+	baseOpts := rclone.MigrationOptions{
+		SourceRemote: "src",
+		SourcePath:   "/",
+		DestRemote:   "dst",
+		DestPath:     "/",
+		MaxFiles:     100,
+	}
+
+	t.Run("warn only", func(t *testing.T) {
+		s := newTestServer(t)
+		job, err := s.launchMigration(httptest.NewRequest("POST", "/api/migrations", nil), baseOpts, jobLinks{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitFinalized(t, s, job)
+
+		if job.SizeCheck == nil || !job.SizeCheck.FilesExceeded || job.SizeCheck.ActualFiles != 500 {
+			t.Errorf("size check = %+v, want 500 files exceeding the limit", job.SizeCheck)
+		}
+	})
+
+	t.Run("enforced", func(t *testing.T) {
+		s := newTestServer(t)
+		opts := baseOpts
+		opts.MaxFilesCheck = true
+		job, err := s.launchMigration(httptest.NewRequest("POST", "/api/migrations", nil), opts, jobLinks{})
+
+		var limitErr *rclone.LimitExceededError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("err = %v, want a LimitExceededError", err)
+		}
+		if job != nil || len(s.activeJobs) != 0 {
+			t.Error("migration was started despite the enforced limit")
+		}
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		s := newTestServer(t)
+		opts := baseOpts
+		opts.MaxFiles = 0
+		job, err := s.launchMigration(httptest.NewRequest("POST", "/api/migrations", nil), opts, jobLinks{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitFinalized(t, s, job)
+		if job.SizeCheck != nil {
+			t.Errorf("size check = %+v, want none without limits", job.SizeCheck)
+		}
+	})
+}
+
+// countCalls returns how many times the fake rclone was run with a command
+func countCalls(t *testing.T, logFile, command string) int {
+	t.Helper()
+	data, err := os.ReadFile(logFile)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, command+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRefreshRemoteHealthSerializesRefreshes(t *testing.T) {
+	logFile := writeFakeRclone(t, `sleep 0.2; echo "      123 index.php"`)
+	s := newTestServer(t)
+	for _, name := range []string{"a", "b"} {
+		if err := s.configManager.AddRemote(rclone.Remote{Name: name, Type: "local"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refreshConcurrently := func(force bool) {
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				report, err := s.refreshRemoteHealth(context.Background(), force)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if report.Total != 2 || report.OK != 2 {
+					t.Errorf("report = %+v, want 2 healthy remotes", report)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	refreshConcurrently(false)
+	if calls := countCalls(t, logFile, "ls"); calls != 2 {
+		t.Errorf("%d remote tests after concurrent refreshes, want 2", calls)
+	}
+
+	// Cached results are reused
+	refreshConcurrently(false)
+	if calls := countCalls(t, logFile, "ls"); calls != 2 {
+		t.Errorf("%d remote tests after cached refreshes, want 2", calls)
+	}
+
+	// Concurrent forced refreshes share one new test
+	refreshConcurrently(true)
+	if calls := countCalls(t, logFile, "ls"); calls != 4 {
+		t.Errorf("%d remote tests after forced refreshes, want 4", calls)
+	}
+}
+
+func TestHandleTestAllRemotes(t *testing.T) {
+	writeFakeRclone(t, `[ "$2" = "bad:" ] && exit 1; echo "      123 index.php"`)
+	s := newTestServer(t)
+	for _, name := range []string{"good", "bad"} {
+		if err := s.configManager.AddRemote(rclone.Remote{Name: name, Type: "local"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleTestAllRemotes(w, httptest.NewRequest("POST", "/api/remotes/test-all?force=true", nil))
+	if w.Code != 200 {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	var report rclone.HealthReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 2 || report.OK != 1 || report.Failed != 1 {
+		t.Errorf("report = %+v, want 1 healthy and 1 failed remote", report)
+	}
+}
+
+// addRemotes adds local remotes with the given names to the server's config
+func addRemotes(t *testing.T, s *Server, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := s.configManager.AddRemote(rclone.Remote{Name: name, Type: "local"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// serve calls handler with the route variables of a mux route
+func serve(handler http.HandlerFunc, method, target string, vars map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	if vars != nil {
+		r = mux.SetURLVars(r, vars)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// retry retries a history entry and returns the new job
+func retry(t *testing.T, s *Server, id string) (*rclone.MigrationJob, map[string]interface{}) {
+	t.Helper()
+	w := serve(s.handleRetryMigration, "POST", "/api/migrations/"+id+"/retry", map[string]string{"id": id})
+	if w.Code != http.StatusOK {
+		t.Fatalf("retry status = %d: %s", w.Code, w.Body)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	s.jobsMux.RLock()
+	job := s.activeJobs[body["job_id"].(string)]
+	s.jobsMux.RUnlock()
+	if job == nil {
+		t.Fatal("retry job is not tracked")
+	}
+	waitFinalized(t, s, job)
+	return job, body
+}
+
+func TestRetryMigrationLineage(t *testing.T) {
+	writeFakeRclone(t, `echo "Transferred:            3 / 10, 30%" >&2; exit 1`)
+	s := newTestServer(t)
+	addRemotes(t, s, "src", "dst")
+
+	first := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "dst", DestPath: "/"})
+	if first.GetStatus() != "failed" {
+		t.Fatalf("status = %s, want failed", first.GetStatus())
+	}
+
+	second, body := retry(t, s, first.ID)
+	if second.RetryOfID != first.ID || second.RetryRootID != first.ID || second.RetryCount != 1 {
+		t.Errorf("first retry links to %q (root %q) with count %d, want %q and 1", second.RetryOfID, second.RetryRootID, second.RetryCount, first.ID)
+	}
+	// The failed run transferred some files, so the retry skips errors
+	if body["ignore_errors"] != true || !strings.Contains(second.Command, "--ignore-errors") {
+		t.Errorf("retry of a partial run does not ignore errors: %v, %s", body, second.Command)
+	}
+
+	// Retrying a retry keeps counting the retries of the original
+	third, _ := retry(t, s, second.ID)
+	if third.RetryOfID != second.ID || third.RetryRootID != first.ID || third.RetryCount != 2 {
+		t.Errorf("second retry links to %q (root %q) with count %d, want %q, root %q and 2", third.RetryOfID, third.RetryRootID, third.RetryCount, second.ID, first.ID)
+	}
+
+	// So does retrying the original again
+	fourth, body := retry(t, s, first.ID)
+	if fourth.RetryOfID != first.ID || fourth.RetryRootID != first.ID || fourth.RetryCount != 3 {
+		t.Errorf("third retry links to %q (root %q) with count %d, want %q and 3", fourth.RetryOfID, fourth.RetryRootID, fourth.RetryCount, first.ID)
+	}
+	if body["retry_root_id"] != first.ID || body["retry_count"] != float64(3) {
+		t.Errorf("response = %v", body)
+	}
+
+	history, err := s.historyStore.Get(third.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.RetryOfID != second.ID || history.RetryRootID != first.ID || history.RetryCount != 2 {
+		t.Errorf("history links to %q (root %q) with count %d, want %q, root %q and 2", history.RetryOfID, history.RetryRootID, history.RetryCount, second.ID, first.ID)
+	}
+	if retries, err := s.historyStore.CountRetries(first.ID); err != nil || retries != 3 {
+		t.Errorf("CountRetries = %d, %v, want 3", retries, err)
+	}
+}
+
+func TestRetryMigrationEmailPassword(t *testing.T) {
+	writeFakeRclone(t, `exit 1`)
+	s := newTestServer(t)
+	addRemotes(t, s, "src", "dst")
+
+	// Port 1 refuses the connection, so the notifications fail fast
+	email := &rclone.EmailConfig{SMTP: "127.0.0.1", Port: 1, Username: "mover", Password: "hunter2", From: "mover@example.com", To: "ops@example.com"}
+	failed := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "dst", DestPath: "/", EmailConfig: email})
+
+	job, body := retry(t, s, failed.ID)
+	if job.Options.EmailConfig != nil {
+		t.Errorf("email config = %+v, want the notification dropped without a password", job.Options.EmailConfig)
+	}
+	if warnings, _ := body["warnings"].([]interface{}); len(warnings) != 1 {
+		t.Errorf("warnings = %v, want the dropped notification reported", body["warnings"])
+	}
+}
+
+func TestRetryMigrationRefused(t *testing.T) {
+	writeFakeRclone(t, `[ "$1" = "copy" ] && [ "$2" = "src:/ok" ] && exit 0; exit 1`)
+	s := newTestServer(t)
+	addRemotes(t, s, "src", "dst")
+
+	completed := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/ok", DestRemote: "dst", DestPath: "/"})
+	gone := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "gone", DestPath: "/"})
+
+	tests := []struct {
+		name string
+		id   string
+		want int
+	}{
+		{"completed migration", completed.ID, http.StatusConflict},
+		{"missing remote", gone.ID, http.StatusUnprocessableEntity},
+		{"unknown migration", "mig-unknown", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := serve(s.handleRetryMigration, "POST", "/api/migrations/"+tt.id+"/retry", map[string]string{"id": tt.id})
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = util.GetRequestID(r.Context())
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	const provided = "0b7f3c2e-5d1a-4c8b-9e6f-2a3b4c5d6e7f"
+	req := httptest.NewRequest("GET", "/api/health", nil)
+	req.Header.Set(util.RequestIDHeader, provided)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get(util.RequestIDHeader); got != provided {
+		t.Errorf("response ID = %q, want the provided %q", got, provided)
+	}
+	if seen != provided {
+		t.Errorf("handler saw ID %q, want %q", seen, provided)
+	}
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/health", nil))
+	generated := w.Header().Get(util.RequestIDHeader)
+	if generated == "" || generated != seen {
+		t.Errorf("generated ID = %q, handler saw %q", generated, seen)
+	}
+}
+
+// logBuffer collects log output; goroutines left by earlier tests may still log
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLog redirects the standard logger for the rest of the test
+func captureLog(t *testing.T) *logBuffer {
+	t.Helper()
+	buf := &logBuffer{}
+	previous := log.Writer()
+	log.SetOutput(buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+	return buf
+}
+
+func TestRequestIDInLogs(t *testing.T) {
+	logs := captureLog(t)
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logRequest(r, "handling %s", r.URL.Path)
+		switch r.URL.Path {
+		case "/api/missing":
+			http.Error(w, "not found", http.StatusNotFound)
+		case "/api/broken":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}
+	}))
+
+	tests := []struct {
+		path   string
+		id     string
+		logged string // status line expected in the logs, empty for none
+	}{
+		{"/api/health", "11111111-1111-4111-8111-111111111111", ""},
+		{"/api/missing", "22222222-2222-4222-8222-222222222222", "GET /api/missing returned 404"},
+		{"/api/broken", "33333333-3333-4333-8333-333333333333", "GET /api/broken returned 500"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set(util.RequestIDHeader, tt.id)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		output := logs.String()
+		if !strings.Contains(output, "["+tt.id+"] handling "+tt.path) {
+			t.Errorf("%s: handler log line without the request ID:\n%s", tt.path, output)
+		}
+		statusLogged := strings.Contains(output, "["+tt.id+"] GET "+tt.path+" returned")
+		if tt.logged == "" && statusLogged {
+			t.Errorf("%s: successful request logged as an error:\n%s", tt.path, output)
+		}
+		if tt.logged != "" && !strings.Contains(output, "["+tt.id+"] "+tt.logged) {
+			t.Errorf("%s: error response not logged with the request ID:\n%s", tt.path, output)
+		}
+	}
+}
+
+func TestFinishedJobLogsRequestID(t *testing.T) {
+	writeFakeRclone(t, `exit 1`)
+	s := newTestServer(t)
+	logs := captureLog(t)
+
+	job, err := s.executor.StartMigration(context.Background(), rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "dst", DestPath: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.RequestID = "44444444-4444-4444-8444-444444444444"
+	if !job.WaitForCompletion(10 * time.Second) {
+		t.Fatal("job did not complete")
+	}
+
+	// The cleanup result cannot be recorded for a job missing from history
+	s.cleanupPartialFiles(job.RequestID, job.ID, job.Options, job.StartTime)
+	if !strings.Contains(logs.String(), "["+job.RequestID+"] Failed to record cleanup of "+job.ID) {
+		t.Errorf("cleanup log line without the request ID:\n%s", logs.String())
+	}
+}
+
+func TestRequestIDMiddlewareKeepsFlush(t *testing.T) {
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("response writer does not implement http.Flusher")
+		}
+		w.Write([]byte("data: ping\n\n"))
+		flusher.Flush()
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/migrations/mig-1/stream", nil))
+	if !w.Flushed {
+		t.Error("Flush was not passed to the underlying writer")
+	}
+}
+
+// replay replays a history entry and returns the response
+func replay(s *Server, id string) *httptest.ResponseRecorder {
+	return serve(s.handleReplayHistory, "POST", "/api/history/"+id+"/replay", map[string]string{"id": id})
+}
+
+func TestReplayHistory(t *testing.T) {
+	writeFakeRclone(t, `exit 0`)
+	s := newTestServer(t)
+	addRemotes(t, s, "src", "dst")
+
+	original := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "dst", DestPath: "/var/www"})
+
+	w := replay(s, original.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("replay status = %d: %s", w.Code, w.Body)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["replay_of_id"] != original.ID {
+		t.Errorf("replay_of_id = %v, want %s", body["replay_of_id"], original.ID)
+	}
+
+	s.jobsMux.RLock()
+	job := s.activeJobs[body["job_id"].(string)]
+	s.jobsMux.RUnlock()
+	if job == nil {
+		t.Fatal("replay job is not tracked")
+	}
+	waitFinalized(t, s, job)
+	if job.Options.DestPath != "/var/www" {
+		t.Errorf("replay dest path = %q, want the original /var/www", job.Options.DestPath)
+	}
+	history, err := s.historyStore.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.ReplayOfID != original.ID {
+		t.Errorf("history replay_of_id = %q, want %s", history.ReplayOfID, original.ID)
+	}
+}
+
+func TestReplayHistoryMissingRemote(t *testing.T) {
+	logFile := writeFakeRclone(t, `exit 0`)
+	s := newTestServer(t)
+	addRemotes(t, s, "src", "dst")
+
+	original := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "dst", DestPath: "/"})
+	if err := s.configManager.DeleteRemote("dst"); err != nil {
+		t.Fatal(err)
+	}
+	copies := countCalls(t, logFile, "copy")
+
+	w := replay(s, original.ID)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), "dst") {
+		t.Errorf("error %q does not name the missing remote", w.Body)
+	}
+	if got := countCalls(t, logFile, "copy"); got != copies {
+		t.Errorf("rclone copy ran %d more time(s) for a refused replay", got-copies)
+	}
+
+	if w := replay(s, "mig-unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown entry status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestAggregateHistory(t *testing.T) {
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	entry := func(status, source, dest string, start time.Time, seconds int, bytes, files int64) rclone.MigrationHistory {
+		return rclone.MigrationHistory{
+			Options:    rclone.MigrationOptions{SourceRemote: source, DestRemote: dest},
+			Status:     status,
+			StartTime:  start,
+			EndTime:    start.Add(time.Duration(seconds) * time.Second),
+			TotalBytes: bytes,
+			TotalFiles: files,
+		}
+	}
+	history := []rclone.MigrationHistory{
+		entry("completed", "old", "new", day.AddDate(0, 0, 1), 60, 1000, 10),
+		entry("failed", "old", "staging", day, 30, 200, 2),
+		entry("cancelled", "blog", "new", day.AddDate(0, 0, 2), 10, 0, 0),
+		entry("completed", "blog", "new", day.AddDate(0, 0, 3), 20, 300, 3),
+	}
+
+	totals := aggregateHistory(history)
+	if totals.count != 4 || totals.successful != 2 || totals.failed != 1 {
+		t.Errorf("count %d, successful %d, failed %d, want 4, 2, 1", totals.count, totals.successful, totals.failed)
+	}
+	if totals.bytes != 1500 || totals.files != 15 || totals.durationSeconds != 120 {
+		t.Errorf("bytes %d, files %d, duration %v, want 1500, 15, 120", totals.bytes, totals.files, totals.durationSeconds)
+	}
+	if !totals.oldest.Equal(day) || !totals.newest.Equal(day.AddDate(0, 0, 3)) {
+		t.Errorf("oldest %v, newest %v", totals.oldest, totals.newest)
+	}
+	// Ties go to the alphabetically first remote
+	if source, dest := mostUsed(totals.sourceCounts), mostUsed(totals.destCounts); source != "blog" || dest != "new" {
+		t.Errorf("most used source %q, dest %q, want blog and new", source, dest)
+	}
+
+	empty := aggregateHistory(nil)
+	if empty.count != 0 || empty.oldest != nil || empty.newest != nil {
+		t.Errorf("empty history totals = %+v", empty)
+	}
+}
+
+func TestHandleVersion(t *testing.T) {
+	s := newTestServer(t)
+	s.rcloneVersion = "v1.68.1"
+
+	version, commit, buildTime := Version, Commit, BuildTime
+	Version, Commit, BuildTime = "1.2.3", "abc123", "2024-01-15T10:00:00Z"
+	t.Cleanup(func() { Version, Commit, BuildTime = version, commit, buildTime })
+
+	w := serve(s.handleVersion, "GET", "/api/version", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"version":        "1.2.3",
+		"commit":         "abc123",
+		"build_time":     "2024-01-15T10:00:00Z",
+		"rclone_version": "v1.68.1",
+	}
+	for key, value := range want {
+		if body[key] != value {
+			t.Errorf("%s = %v, want %q", key, body[key], value)
+		}
+	}
+	if body["go_version"] != runtime.Version() {
+		t.Errorf("go_version = %v, want %s", body["go_version"], runtime.Version())
+	}
+	if len(body) != len(want)+1 {
+		t.Errorf("unexpected fields in %v", body)
+	}
+}
+
+func TestGetBatchMigrationStatus(t *testing.T) {
+	writeFakeRclone(t, `[ "$3" = "eu:/" ] && exit 0; exit 1`)
+	s := newTestServer(t)
+
+	eu := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "eu", DestPath: "/"})
+	us := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "us", DestPath: "/"})
+
+	getBatch := func(subJobIDs ...string) map[string]interface{} {
+		t.Helper()
+		s.batchMux.Lock()
+		s.batchJobs["batch-1"] = &rclone.BatchJob{MasterID: "batch-1", SubJobIDs: subJobIDs, StartTime: time.Now()}
+		s.batchMux.Unlock()
+
+		w := serve(s.handleGetBatchMigration, "GET", "/api/migrations/batch/batch-1", map[string]string{"id": "batch-1"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	body := getBatch(eu.ID, us.ID)
+	if body["status"] != "failed" {
+		t.Errorf("batch status = %v, want failed", body["status"])
+	}
+	jobs := body["jobs"].([]interface{})
+	if len(jobs) != 2 {
+		t.Fatalf("%d jobs, want 2", len(jobs))
+	}
+	for i, want := range []string{"completed", "failed"} {
+		if status := jobs[i].(map[string]interface{})["status"]; status != want {
+			t.Errorf("job %d status = %v, want %s", i, status, want)
+		}
+	}
+
+	if body := getBatch(eu.ID); body["status"] != "completed" {
+		t.Errorf("batch status = %v, want completed", body["status"])
+	}
+	if body := getBatch(eu.ID, "mig-dropped"); body["status"] != "failed" {
+		t.Errorf("batch with a dropped sub-job status = %v, want failed", body["status"])
+	}
+
+	if w := serve(s.handleGetBatchMigration, "GET", "/api/migrations/batch/nope", map[string]string{"id": "nope"}); w.Code != http.StatusNotFound {
+		t.Errorf("unknown batch status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// uploadRequest builds a multipart upload of content named filename
+func uploadRequest(t *testing.T, remote, remotePath, filename, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	form.Close()
+
+	r := httptest.NewRequest("POST", "/api/remotes/"+remote+"/upload?path="+url.QueryEscape(remotePath), &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	return mux.SetURLVars(r, map[string]string{"name": remote})
+}
+
+func TestUploadFile(t *testing.T) {
+	received := filepath.Join(t.TempDir(), "received")
+	logFile := writeFakeRclone(t, `[ "$1" = "copyto" ] && cp "$2" "`+received+`"`)
+	s := newTestServer(t)
+	addRemotes(t, s, "site")
+
+	tests := []struct {
+		name     string
+		path     string
+		wantPath string
+	}{
+		{"file path", "/var/www/robots.txt", "/var/www/robots.txt"},
+		{"directory keeps the file name", "/var/www/", "/var/www/upload.txt"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		s.handleUploadFile(w, uploadRequest(t, "site", tt.path, "upload.txt", "User-agent: *"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", tt.name, w.Code, w.Body)
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body["path"] != tt.wantPath || body["size"] != float64(len("User-agent: *")) {
+			t.Errorf("%s: response = %v", tt.name, body)
+		}
+		if data, err := os.ReadFile(received); err != nil || string(data) != "User-agent: *" {
+			t.Errorf("%s: rclone received %q (%v)", tt.name, data, err)
+		}
+		if got := countCalls(t, logFile, "copyto"); got == 0 {
+			t.Errorf("%s: rclone copyto not called", tt.name)
+		}
+	}
+
+	// The temporary copy is removed once uploaded
+	calls, _ := os.ReadFile(logFile)
+	for _, call := range strings.Split(strings.TrimSpace(string(calls)), "\n") {
+		args := strings.Fields(call)
+		if _, err := os.Stat(args[1]); args[0] == "copyto" && err == nil {
+			t.Errorf("temporary file %s left behind", args[1])
+		}
+	}
+}
+
+func TestUploadFileRefused(t *testing.T) {
+	logFile := writeFakeRclone(t, `exit 0`)
+	s := newTestServer(t)
+	addRemotes(t, s, "site")
+
+	w := httptest.NewRecorder()
+	s.handleUploadFile(w, uploadRequest(t, "gone", "/var/www/index.php", "index.php", "<?php"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown remote status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleUploadFile(w, uploadRequest(t, "site", "", "index.php", "<?php"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("missing path status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	if got := countCalls(t, logFile, "copyto"); got != 0 {
+		t.Errorf("rclone copyto ran %d time(s) for refused uploads", got)
+	}
+}
+
+func TestRestoreEmailPassword(t *testing.T) {
+	stripped := &rclone.EmailConfig{SMTP: "smtp.example.com", Username: "mover", From: "mover@example.com", To: "ops@example.com"}
+
+	opts := rclone.MigrationOptions{EmailConfig: stripped}
+	if warnings := restoreEmailPassword(&opts, "hunter2"); len(warnings) != 0 || opts.EmailConfig.Password != "hunter2" {
+		t.Errorf("password = %q, warnings = %q", opts.EmailConfig.Password, warnings)
+	}
+	if stripped.Password != "" {
+		t.Error("history options modified")
+	}
+
+	opts = rclone.MigrationOptions{EmailConfig: stripped}
+	if warnings := restoreEmailPassword(&opts, ""); len(warnings) != 1 || opts.EmailConfig != nil {
+		t.Errorf("email config = %+v, warnings = %q, want the notification dropped with a warning", opts.EmailConfig, warnings)
+	}
+
+	// Servers without a login do not need a password
+	anonymous := *stripped
+	anonymous.Username = ""
+	opts = rclone.MigrationOptions{EmailConfig: &anonymous}
+	if warnings := restoreEmailPassword(&opts, ""); len(warnings) != 0 || opts.EmailConfig == nil {
+		t.Errorf("email config = %+v, warnings = %q, want it kept", opts.EmailConfig, warnings)
+	}
+}
+
+func TestReplayHistoryEmailPassword(t *testing.T) {
+	writeFakeRclone(t, `exit 0`)
+	s := newTestServer(t)
+	addRemotes(t, s, "src", "dst")
+
+	// Port 1 refuses the connection, so the notifications fail fast
+	email := &rclone.EmailConfig{SMTP: "127.0.0.1", Port: 1, Username: "mover", Password: "hunter2", From: "mover@example.com", To: "ops@example.com"}
+	original := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "dst", DestPath: "/", EmailConfig: email})
+
+	replayWithBody := func(body string) (*rclone.MigrationJob, []interface{}) {
+		t.Helper()
+		r := httptest.NewRequest("POST", "/api/history/"+original.ID+"/replay", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.handleReplayHistory(w, mux.SetURLVars(r, map[string]string{"id": original.ID}))
+		if w.Code != http.StatusOK {
+			t.Fatalf("replay status = %d: %s", w.Code, w.Body)
+		}
+		var resp map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		s.jobsMux.RLock()
+		job := s.activeJobs[resp["job_id"].(string)]
+		s.jobsMux.RUnlock()
+		if job == nil {
+			t.Fatal("replay job is not tracked")
+		}
+		waitFinalized(t, s, job)
+		return job, resp["warnings"].([]interface{})
+	}
+
+	job, warnings := replayWithBody(`{"email_password":"hunter2"}`)
+	if job.Options.EmailConfig == nil || job.Options.EmailConfig.Password != "hunter2" {
+		t.Errorf("email config = %+v, want the given password", job.Options.EmailConfig)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+
+	job, warnings = replayWithBody("")
+	if job.Options.EmailConfig != nil {
+		t.Errorf("email config = %+v, want the notification dropped", job.Options.EmailConfig)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].(string), "email_password") {
+		t.Errorf("warnings = %v, want the dropped notification reported", warnings)
+	}
+
+	history, err := s.historyStore.Get(original.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.Options.EmailConfig.Password != "" {
+		t.Error("SMTP password stored in history")
+	}
+}
+
+// verify starts a verification of a history entry and returns its job
+func verify(t *testing.T, s *Server, id string) *rclone.VerifyJob {
+	t.Helper()
+	w := serve(s.handleVerifyMigration, "POST", "/api/migrations/"+id+"/verify", map[string]string{"id": id})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("verify status = %d: %s", w.Code, w.Body)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	s.verifyMux.RLock()
+	defer s.verifyMux.RUnlock()
+	verifyJob := s.verifyJobs[body["verify_job_id"].(string)]
+	if verifyJob == nil {
+		t.Fatal("verification is not tracked")
+	}
+	return verifyJob
+}
+
+// verifyStatus reads the status of a verification under its lock
+func verifyStatus(s *Server, verifyJob *rclone.VerifyJob) (string, string) {
+	s.verifyMux.RLock()
+	defer s.verifyMux.RUnlock()
+	return verifyJob.Status, verifyJob.Error
+}
+
+func TestVerifyMigration(t *testing.T) {
+	writeFakeRclone(t, `[ "$1" = "check" ] && printf '= index.php\n+ image.jpg\n' && exit 1; exit 0`)
+	s := newTestServer(t)
+
+	job := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "dst", DestPath: "/"})
+	verifyJob := verify(t, s, job.ID)
+	s.verifyWG.Wait()
+
+	if status, errMsg := verifyStatus(s, verifyJob); status != "completed" {
+		t.Fatalf("status = %s (%s), want completed", status, errMsg)
+	}
+	history, err := s.historyStore.Get(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.Verification == nil || history.Verification.MissingDest != 1 || history.Verification.Matched != 1 {
+		t.Errorf("verification in history = %+v", history.Verification)
+	}
+
+	s.verifyMux.RLock()
+	running := len(s.verifyCancels)
+	s.verifyMux.RUnlock()
+	if running != 0 {
+		t.Errorf("%d finished verification(s) still cancellable", running)
+	}
+}
+
+func TestCancelVerifications(t *testing.T) {
+	writeFakeRclone(t, `[ "$1" = "check" ] && exec sleep 30; exit 0`)
+	s := newTestServer(t)
+
+	job := finishJob(t, s, rclone.MigrationOptions{SourceRemote: "src", SourcePath: "/", DestRemote: "dst", DestPath: "/"})
+	verifyJob := verify(t, s, job.ID)
+
+	start := time.Now()
+	s.cancelVerifications()
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancelling took %s", elapsed)
+	}
+	if status, errMsg := verifyStatus(s, verifyJob); status != "cancelled" {
+		t.Errorf("status = %s (%s), want cancelled", status, errMsg)
+	}
+}
+
+func TestPruneVerifyJobs(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Now()
+	old := now.Add(-constants.VerifyJobRetention - time.Minute)
+	recent := now.Add(-time.Minute)
+	s.verifyJobs = map[string]*rclone.VerifyJob{
+		"ver-old":     {ID: "ver-old", Status: "completed", EndTime: &old},
+		"ver-recent":  {ID: "ver-recent", Status: "failed", EndTime: &recent},
+		"ver-running": {ID: "ver-running", Status: "running", StartTime: old},
+	}
+
+	s.pruneVerifyJobs(now)
+
+	if _, kept := s.verifyJobs["ver-old"]; kept {
+		t.Error("old verification kept")
+	}
+	for _, id := range []string{"ver-recent", "ver-running"} {
+		if _, kept := s.verifyJobs[id]; !kept {
+			t.Errorf("%s evicted", id)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package main
+
+// Build metadata, set at build time with
+//
+//	go build -ldflags "-X main.Version=1.2.3 -X main.Commit=abc123 -X main.BuildTime=2024-01-15T10:00:00Z" ./cmd/server
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
@@ -5,18 +5,18 @@ go 1.24.0
 toolchain go1.24.7
 
 require (
+	github.com/google/uuid v1.6.0
+	github.com/gorilla/mux v1.8.1
 	github.com/jlaffaye/ftp v0.2.0
 	github.com/pkg/sftp v1.13.10
+	github.com/rs/cors v1.11.1
 	golang.org/x/crypto v0.43.0
+	gopkg.in/ini.v1 v1.67.0
 )
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/gorilla/mux v1.8.1 // indirect
 	github.com/hashicorp/errwrap v1.0.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/kr/fs v0.1.0 // indirect
-	github.com/rs/cors v1.11.1 // indirect
 	golang.org/x/sys v0.37.0 // indirect
-	gopkg.in/ini.v1 v1.67.0 // indirect
 )
@@ -0,0 +1,157 @@
+// Package config loads the HTTP server configuration from the environment
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+)
+
+// ServerConfig holds the settings of the HTTP API server
+type ServerConfig struct {
+	// Address to listen on, e.g. "127.0.0.1:8080"
+	BindAddress string
+
+	// TLS certificate and key files; TLS is enabled when both are set
+	TLSCert string
+	TLSKey  string
+
+	// Generate and reuse a self-signed certificate when no certificate is given
+	TLSSelfSigned bool
+
+	// Memory limit for jobs in MB
+	MaxMemoryMB int
+
+	// File previews: how many bytes are returned and which MIME types may be
+	// previewed ("type/*" wildcards are allowed)
+	MaxPreviewBytes         int64
+	PreviewAllowedMimeTypes []string
+
+	// New SSH connections allowed per minute, by "host:port"
+	HostConnectionLimits map[string]int
+}
+
+// defaultPreviewMimeTypes restricts previews to text files
+var defaultPreviewMimeTypes = []string{"text/*", "application/json", "application/xml"}
+
+// LoadConfig reads the server configuration from the environment:
+//
+//	WEBSITE_MOVER_BIND_ADDRESS            listen address (default 127.0.0.1:8080)
+//	WEBSITE_MOVER_TLS_CERT                TLS certificate file
+//	WEBSITE_MOVER_TLS_KEY                 TLS private key file
+//	WEBSITE_MOVER_TLS_SELF_SIGNED         "true" to use a generated self-signed certificate
+//	WEBSITE_MOVER_MAX_MEMORY_MB           memory limit for jobs (default 500)
+//	WEBSITE_MOVER_MAX_PREVIEW_BYTES       size of file previews (default 8192)
+//	WEBSITE_MOVER_PREVIEW_MIME_TYPES      comma-separated MIME types that may be previewed
+//	WEBSITE_MOVER_HOST_CONNECTION_LIMITS  per-host connection rate limits, e.g. "example.com:22=5,10.0.0.2:2222=10"
+func LoadConfig() (*ServerConfig, error) {
+	cfg := &ServerConfig{
+		BindAddress: os.Getenv("WEBSITE_MOVER_BIND_ADDRESS"),
+		TLSCert:     os.Getenv("WEBSITE_MOVER_TLS_CERT"),
+		TLSKey:      os.Getenv("WEBSITE_MOVER_TLS_KEY"),
+		MaxMemoryMB: constants.DefaultMaxMemoryMB,
+
+		MaxPreviewBytes:         constants.DefaultMaxPreviewBytes,
+		PreviewAllowedMimeTypes: defaultPreviewMimeTypes,
+	}
+
+	if cfg.BindAddress == "" {
+		cfg.BindAddress = constants.ServerBindAddress + ":" + constants.DefaultServerPort
+	}
+
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return nil, fmt.Errorf("WEBSITE_MOVER_TLS_CERT and WEBSITE_MOVER_TLS_KEY must be set together")
+	}
+
+	if value := os.Getenv("WEBSITE_MOVER_TLS_SELF_SIGNED"); value != "" {
+		selfSigned, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WEBSITE_MOVER_TLS_SELF_SIGNED value %q: %w", value, err)
+		}
+		cfg.TLSSelfSigned = selfSigned && cfg.TLSCert == ""
+	}
+
+	if value := os.Getenv("WEBSITE_MOVER_MAX_MEMORY_MB"); value != "" {
+		maxMemory, err := strconv.Atoi(value)
+		if err != nil || maxMemory < 0 {
+			return nil, fmt.Errorf("invalid WEBSITE_MOVER_MAX_MEMORY_MB value %q: expected a number of megabytes", value)
+		}
+		cfg.MaxMemoryMB = maxMemory
+	}
+
+	if value := os.Getenv("WEBSITE_MOVER_MAX_PREVIEW_BYTES"); value != "" {
+		maxPreview, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || maxPreview <= 0 {
+			return nil, fmt.Errorf("invalid WEBSITE_MOVER_MAX_PREVIEW_BYTES value %q: expected a positive number of bytes", value)
+		}
+		cfg.MaxPreviewBytes = maxPreview
+	}
+
+	if value := os.Getenv("WEBSITE_MOVER_PREVIEW_MIME_TYPES"); value != "" {
+		cfg.PreviewAllowedMimeTypes = nil
+		for _, mimeType := range strings.Split(value, ",") {
+			if mimeType = strings.TrimSpace(mimeType); mimeType != "" {
+				cfg.PreviewAllowedMimeTypes = append(cfg.PreviewAllowedMimeTypes, mimeType)
+			}
+		}
+	}
+
+	if value := os.Getenv("WEBSITE_MOVER_HOST_CONNECTION_LIMITS"); value != "" {
+		limits, err := parseHostConnectionLimits(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WEBSITE_MOVER_HOST_CONNECTION_LIMITS value %q: %w", value, err)
+		}
+		cfg.HostConnectionLimits = limits
+	}
+
+	return cfg, nil
+}
+
+// parseHostConnectionLimits parses a comma-separated list of host:port=limit pairs
+func parseHostConnectionLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		hostPort, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected host:port=connections_per_minute, got %q", entry)
+		}
+		if _, _, err := net.SplitHostPort(strings.TrimSpace(hostPort)); err != nil {
+			return nil, fmt.Errorf("invalid host %q: %w", hostPort, err)
+		}
+		perMinute, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || perMinute < 0 {
+			return nil, fmt.Errorf("invalid limit %q for %s: expected connections per minute (0 disables the limit)", limit, hostPort)
+		}
+		limits[strings.TrimSpace(hostPort)] = perMinute
+	}
+	return limits, nil
+}
+
+// TLSEnabled reports whether the server should serve HTTPS
+func (c *ServerConfig) TLSEnabled() bool {
+	return c.TLSCert != "" || c.TLSSelfSigned
+}
+
+// URL returns the base URL of the server for display
+func (c *ServerConfig) URL() string {
+	scheme := "http"
+	if c.TLSEnabled() {
+		scheme = "https"
+	}
+
+	address := c.BindAddress
+	if strings.HasPrefix(address, ":") {
+		address = "localhost" + address
+	}
+
+	return scheme + "://" + address
+}
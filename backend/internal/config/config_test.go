@@ -0,0 +1,137 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+)
+
+// configEnv lists every variable read by LoadConfig
+var configEnv = []string{
+	"WEBSITE_MOVER_BIND_ADDRESS",
+	"WEBSITE_MOVER_TLS_CERT",
+	"WEBSITE_MOVER_TLS_KEY",
+	"WEBSITE_MOVER_TLS_SELF_SIGNED",
+	"WEBSITE_MOVER_MAX_MEMORY_MB",
+	"WEBSITE_MOVER_MAX_PREVIEW_BYTES",
+	"WEBSITE_MOVER_PREVIEW_MIME_TYPES",
+	"WEBSITE_MOVER_HOST_CONNECTION_LIMITS",
+}
+
+// loadWithEnv runs LoadConfig with only the given variables set
+func loadWithEnv(t *testing.T, env map[string]string) (*ServerConfig, error) {
+	t.Helper()
+	for _, name := range configEnv {
+		t.Setenv(name, env[name])
+	}
+	return LoadConfig()
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := loadWithEnv(t, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := constants.ServerBindAddress + ":" + constants.DefaultServerPort; cfg.BindAddress != want {
+		t.Errorf("BindAddress = %q, want %q", cfg.BindAddress, want)
+	}
+	if cfg.TLSEnabled() {
+		t.Error("TLS enabled by default")
+	}
+	if cfg.MaxMemoryMB != constants.DefaultMaxMemoryMB {
+		t.Errorf("MaxMemoryMB = %d, want %d", cfg.MaxMemoryMB, constants.DefaultMaxMemoryMB)
+	}
+	if cfg.MaxPreviewBytes != constants.DefaultMaxPreviewBytes {
+		t.Errorf("MaxPreviewBytes = %d, want %d", cfg.MaxPreviewBytes, constants.DefaultMaxPreviewBytes)
+	}
+	if !reflect.DeepEqual(cfg.PreviewAllowedMimeTypes, defaultPreviewMimeTypes) {
+		t.Errorf("PreviewAllowedMimeTypes = %q", cfg.PreviewAllowedMimeTypes)
+	}
+	if cfg.HostConnectionLimits != nil {
+		t.Errorf("HostConnectionLimits = %v, want none", cfg.HostConnectionLimits)
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	cfg, err := loadWithEnv(t, map[string]string{
+		"WEBSITE_MOVER_BIND_ADDRESS":           "0.0.0.0:9090",
+		"WEBSITE_MOVER_TLS_CERT":               "/etc/mover/cert.pem",
+		"WEBSITE_MOVER_TLS_KEY":                "/etc/mover/key.pem",
+		"WEBSITE_MOVER_MAX_MEMORY_MB":          "1024",
+		"WEBSITE_MOVER_MAX_PREVIEW_BYTES":      "65536",
+		"WEBSITE_MOVER_PREVIEW_MIME_TYPES":     "text/plain, image/*,",
+		"WEBSITE_MOVER_HOST_CONNECTION_LIMITS": "example.com:22=5, 10.0.0.2:2222=0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.BindAddress != "0.0.0.0:9090" {
+		t.Errorf("BindAddress = %q", cfg.BindAddress)
+	}
+	if cfg.TLSCert != "/etc/mover/cert.pem" || cfg.TLSKey != "/etc/mover/key.pem" {
+		t.Errorf("TLS files = %q, %q", cfg.TLSCert, cfg.TLSKey)
+	}
+	if cfg.URL() != "https://0.0.0.0:9090" {
+		t.Errorf("URL = %q", cfg.URL())
+	}
+	if cfg.MaxMemoryMB != 1024 || cfg.MaxPreviewBytes != 65536 {
+		t.Errorf("MaxMemoryMB = %d, MaxPreviewBytes = %d", cfg.MaxMemoryMB, cfg.MaxPreviewBytes)
+	}
+	if want := []string{"text/plain", "image/*"}; !reflect.DeepEqual(cfg.PreviewAllowedMimeTypes, want) {
+		t.Errorf("PreviewAllowedMimeTypes = %q, want %q", cfg.PreviewAllowedMimeTypes, want)
+	}
+	if want := map[string]int{"example.com:22": 5, "10.0.0.2:2222": 0}; !reflect.DeepEqual(cfg.HostConnectionLimits, want) {
+		t.Errorf("HostConnectionLimits = %v, want %v", cfg.HostConnectionLimits, want)
+	}
+}
+
+func TestLoadConfigSelfSigned(t *testing.T) {
+	cfg, err := loadWithEnv(t, map[string]string{
+		"WEBSITE_MOVER_BIND_ADDRESS":    ":8443",
+		"WEBSITE_MOVER_TLS_SELF_SIGNED": "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.TLSSelfSigned || cfg.URL() != "https://localhost:8443" {
+		t.Errorf("TLSSelfSigned = %v, URL = %q", cfg.TLSSelfSigned, cfg.URL())
+	}
+
+	// A provided certificate takes precedence over the self-signed one
+	cfg, err = loadWithEnv(t, map[string]string{
+		"WEBSITE_MOVER_TLS_CERT":        "cert.pem",
+		"WEBSITE_MOVER_TLS_KEY":         "key.pem",
+		"WEBSITE_MOVER_TLS_SELF_SIGNED": "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TLSSelfSigned {
+		t.Error("self-signed certificate used although a certificate was given")
+	}
+}
+
+func TestLoadConfigInvalidEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"cert without key", map[string]string{"WEBSITE_MOVER_TLS_CERT": "cert.pem"}},
+		{"key without cert", map[string]string{"WEBSITE_MOVER_TLS_KEY": "key.pem"}},
+		{"self-signed not a boolean", map[string]string{"WEBSITE_MOVER_TLS_SELF_SIGNED": "yes please"}},
+		{"memory not a number", map[string]string{"WEBSITE_MOVER_MAX_MEMORY_MB": "1GB"}},
+		{"negative memory", map[string]string{"WEBSITE_MOVER_MAX_MEMORY_MB": "-1"}},
+		{"zero preview size", map[string]string{"WEBSITE_MOVER_MAX_PREVIEW_BYTES": "0"}},
+		{"limit without host", map[string]string{"WEBSITE_MOVER_HOST_CONNECTION_LIMITS": "5"}},
+		{"limit without port", map[string]string{"WEBSITE_MOVER_HOST_CONNECTION_LIMITS": "example.com=5"}},
+		{"negative limit", map[string]string{"WEBSITE_MOVER_HOST_CONNECTION_LIMITS": "example.com:22=-1"}},
+	}
+	for _, tt := range tests {
+		if _, err := loadWithEnv(t, tt.env); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -12,6 +12,22 @@ const (
 
 	// TCPDialTimeout is the timeout for TCP connection attempts
 	TCPDialTimeout = 5 * time.Second
+
+	// DefaultConnectTimeout is the default per-remote connection establishment timeout
+	DefaultConnectTimeout = DefaultConnectionTimeout
+
+	// DefaultOperationTimeout is the default per-remote timeout for individual file operations
+	DefaultOperationTimeout = DefaultSFTPTimeout
+
+	// MaxConfigurableTimeout is the upper bound for user-configured timeouts
+	MaxConfigurableTimeout = 5 * time.Minute
+
+	// DefaultSFTPPoolSize is the default maximum number of pooled SFTP connections per server
+	DefaultSFTPPoolSize = 8
+
+	// DefaultMaxNewConnectionsPerMinute limits new SSH connections to the same host,
+	// to stay under the per-IP connection limits of shared hosting
+	DefaultMaxNewConnectionsPerMinute = 30
 )
 
 // File transfer constants
@@ -48,6 +64,56 @@ const (
 
 	// ServerBindAddress is the address the server binds to (localhost only for security)
 	ServerBindAddress = "127.0.0.1"
+
+	// ShutdownTimeout is how long the HTTP server gets to finish in-flight requests
+	ShutdownTimeout = 5 * time.Second
+
+	// JobDrainTimeout is how long shutdown waits for each active migration to finish
+	JobDrainTimeout = 30 * time.Second
+
+	// DefaultMaxMemoryMB is the heap size above which jobs start keeping less output
+	DefaultMaxMemoryMB = 500
+
+	// DefaultMaxPreviewBytes is how much of a remote file is returned by a preview (8KB)
+	DefaultMaxPreviewBytes = 8 * 1024
+)
+
+// Job ID prefixes, followed by a UUID v4
+const (
+	ScanJobIDPrefix      = "scan-"
+	PlanJobIDPrefix      = "plan-"
+	TransferJobIDPrefix  = "xfer-"
+	MigrationJobIDPrefix = "mig-"
+	VerifyJobIDPrefix    = "verify-"
+	BatchJobIDPrefix     = "batch-"
+)
+
+// Rclone constants
+const (
+	// MinRcloneVersion is the oldest rclone release whose output format is supported
+	MinRcloneVersion = "1.60.0"
+
+	// SizeCheckTimeout bounds the rclone size run that checks a migration against its limits
+	SizeCheckTimeout = 30 * time.Second
+
+	// CleanupTimeout bounds the removal of partial files after a failed migration
+	CleanupTimeout = 5 * time.Minute
+
+	// VerifyTimeout bounds the rclone check of a post-migration verification
+	VerifyTimeout = 30 * time.Minute
+
+	// VerifyJobRetention is how long a finished verification can still be
+	// fetched by ID; its result stays in the history entry afterwards
+	VerifyJobRetention = time.Hour
+
+	// RemoteTestConcurrency is how many remotes are tested at once when testing all of them
+	RemoteTestConcurrency = 5
+
+	// RemoteTestAllTimeout bounds a test of all configured remotes
+	RemoteTestAllTimeout = 60 * time.Second
+
+	// RemoteHealthCacheTTL is how long the results of testing all remotes are reused
+	RemoteHealthCacheTTL = 5 * time.Minute
 )
 
 // Transfer scoring constants
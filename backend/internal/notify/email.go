@@ -0,0 +1,185 @@
+// Package notify sends notifications about finished migrations
+package notify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonzague/website-mover/backend/internal/rclone"
+)
+
+// maxFailedPathsInEmail is how many failed file paths are listed in a notification
+const maxFailedPathsInEmail = 10
+
+// SendMigrationEmail sends a plain-text summary of a finished migration
+func SendMigrationEmail(result *rclone.MigrationHistory, cfg rclone.EmailConfig) error {
+	subject := fmt.Sprintf("[Website Mover] Migration %s: %s:%s → %s:%s",
+		result.Status,
+		result.Options.SourceRemote, result.Options.SourcePath,
+		result.Options.DestRemote, result.Options.DestPath)
+
+	return SendEmail(cfg, subject, migrationEmailBody(result))
+}
+
+// SendEmail sends a plain-text email using the given SMTP settings
+func SendEmail(cfg rclone.EmailConfig, subject, body string) error {
+	if cfg.SMTP == "" || cfg.From == "" || cfg.To == "" {
+		return fmt.Errorf("smtp server, from and to addresses are required")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+		if cfg.TLS {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(cfg.SMTP, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: cfg.SMTP}
+
+	var client *smtp.Client
+	if cfg.TLS {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", addr, tlsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", addr, err)
+		}
+		client, err = smtp.NewClient(conn, cfg.SMTP)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to start SMTP session: %w", err)
+		}
+	} else {
+		conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", addr, err)
+		}
+		client, err = smtp.NewClient(conn, cfg.SMTP)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to start SMTP session: %w", err)
+		}
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return fmt.Errorf("STARTTLS failed: %w", err)
+			}
+		}
+	}
+	defer client.Close()
+
+	if cfg.Username != "" {
+		auth := smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTP)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(cfg.From); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, to := range strings.Split(cfg.To, ",") {
+		if to = strings.TrimSpace(to); to == "" {
+			continue
+		}
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := writer.Write(buildMessage(cfg.From, cfg.To, subject, body)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// buildMessage formats the headers and body of a plain-text email
+func buildMessage(from, to, subject, body string) []byte {
+	var msg strings.Builder
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + to + "\r\n")
+	msg.WriteString("Subject: " + mimeEncodeHeader(subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(msg.String())
+}
+
+// mimeEncodeHeader encodes non-ASCII header values (e.g. the arrow in the subject)
+func mimeEncodeHeader(value string) string {
+	for _, r := range value {
+		if r > 127 {
+			return mime.QEncoding.Encode("utf-8", value)
+		}
+	}
+	return value
+}
+
+// migrationEmailBody renders the summary of a finished migration
+func migrationEmailBody(result *rclone.MigrationHistory) string {
+	var body strings.Builder
+	duration := result.DurationSeconds()
+
+	fmt.Fprintf(&body, "Status:            %s\n", result.Status)
+	fmt.Fprintf(&body, "Source:            %s:%s\n", result.Options.SourceRemote, result.Options.SourcePath)
+	fmt.Fprintf(&body, "Destination:       %s:%s\n", result.Options.DestRemote, result.Options.DestPath)
+	fmt.Fprintf(&body, "Start time:        %s\n", result.StartTime.Format(time.RFC1123))
+	fmt.Fprintf(&body, "End time:          %s\n", result.EndTime.Format(time.RFC1123))
+	fmt.Fprintf(&body, "Duration:          %s\n", result.Duration)
+	fmt.Fprintf(&body, "Files transferred: %d\n", result.TotalFiles)
+	fmt.Fprintf(&body, "Bytes transferred: %d\n", result.TotalBytes)
+	if duration > 0 {
+		fmt.Fprintf(&body, "Average speed:     %.2f MB/s\n", float64(result.TotalBytes)/1e6/duration)
+	}
+	fmt.Fprintf(&body, "Errors:            %d\n", result.ErrorCount())
+
+	if failed := failedPaths(result.Output, maxFailedPathsInEmail); len(failed) > 0 {
+		body.WriteString("\nFailed files:\n")
+		for _, path := range failed {
+			body.WriteString("  " + path + "\n")
+		}
+	}
+
+	return body.String()
+}
+
+// failedPaths extracts file paths from rclone lines such as
+// "2024/01/15 10:30:00 ERROR : wp-content/image.jpg: Failed to copy: ..."
+func failedPaths(output []string, limit int) []string {
+	paths := []string{}
+	for _, line := range output {
+		idx := strings.Index(line, "ERROR : ")
+		if idx == -1 {
+			continue
+		}
+		rest := line[idx+len("ERROR : "):]
+		// Retry summaries are not about a single file
+		if strings.HasPrefix(rest, "Attempt ") {
+			continue
+		}
+		sep := strings.Index(rest, ": ")
+		if sep <= 0 {
+			continue
+		}
+		paths = append(paths, rest[:sep])
+		if len(paths) == limit {
+			break
+		}
+	}
+	return paths
+}
@@ -0,0 +1,178 @@
+package notify
+
+import (
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonzague/website-mover/backend/internal/rclone"
+)
+
+// smtpSession is what the fake SMTP server received
+type smtpSession struct {
+	auth string // decoded AUTH PLAIN credentials
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPServer accepts a single SMTP session on a local port, offering
+// AUTH PLAIN but not STARTTLS, and rejects recipients at blocked.example
+func startSMTPServer(t *testing.T) (port int, sessions <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+		var session smtpSession
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost fake ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250-localhost")
+				tp.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				encoded := strings.TrimPrefix(arg, "PLAIN ")
+				decoded, _ := base64.StdEncoding.DecodeString(encoded)
+				session.auth = string(decoded)
+				tp.PrintfLine("235 Authentication successful")
+			case "MAIL":
+				session.from = arg
+				tp.PrintfLine("250 OK")
+			case "RCPT":
+				if strings.Contains(arg, "@blocked.example") {
+					tp.PrintfLine("550 No such user")
+					continue
+				}
+				session.to = append(session.to, arg)
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				session.data = string(data)
+				tp.PrintfLine("250 Queued")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				received <- session
+				return
+			default:
+				tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func testEmailConfig(port int) rclone.EmailConfig {
+	return rclone.EmailConfig{
+		SMTP:     "127.0.0.1",
+		Port:     port,
+		Username: "mover",
+		Password: "hunter2",
+		From:     "mover@example.com",
+		To:       "ops@example.com, web@example.com",
+	}
+}
+
+func TestSendMigrationEmail(t *testing.T) {
+	port, sessions := startSMTPServer(t)
+
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	result := &rclone.MigrationHistory{
+		Options: rclone.MigrationOptions{
+			SourceRemote: "old", SourcePath: "/var/www",
+			DestRemote: "new", DestPath: "/srv/www",
+		},
+		Status:     "failed",
+		StartTime:  start,
+		EndTime:    start.Add(10 * time.Second),
+		Duration:   "10s",
+		TotalFiles: 3,
+		TotalBytes: 5_000_000,
+		Output: []string{
+			"2024/03/01 10:00:05 ERROR : wp-config.php: Failed to copy: permission denied",
+			"2024/03/01 10:00:09 ERROR : Attempt 1/3 failed with 1 errors",
+		},
+	}
+
+	if err := SendMigrationEmail(result, testEmailConfig(port)); err != nil {
+		t.Fatal(err)
+	}
+
+	var session smtpSession
+	select {
+	case session = <-sessions:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no SMTP session completed")
+	}
+
+	if session.auth != "\x00mover\x00hunter2" {
+		t.Errorf("AUTH PLAIN credentials = %q", session.auth)
+	}
+	if session.from != "FROM:<mover@example.com>" {
+		t.Errorf("MAIL %s", session.from)
+	}
+	if len(session.to) != 2 || session.to[0] != "TO:<ops@example.com>" || session.to[1] != "TO:<web@example.com>" {
+		t.Errorf("RCPT %q, want both recipients", session.to)
+	}
+
+	// ReadDotBytes turns the CRLF line endings back into LF
+	for _, want := range []string{
+		"To: ops@example.com, web@example.com\n",
+		"Subject: =?utf-8?q?",
+		"Content-Type: text/plain; charset=UTF-8\n",
+		"Status:            failed\n",
+		"Destination:       new:/srv/www\n",
+		"Average speed:     0.50 MB/s\n",
+		"Errors:            2\n",
+		"Failed files:\n  wp-config.php\n",
+	} {
+		if !strings.Contains(session.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, session.data)
+		}
+	}
+	if strings.Contains(session.data, "Attempt 1/3") {
+		t.Error("retry summary listed as a failed file")
+	}
+}
+
+func TestSendEmailRejectedRecipient(t *testing.T) {
+	port, _ := startSMTPServer(t)
+
+	cfg := testEmailConfig(port)
+	cfg.To = "ops@blocked.example"
+	err := SendEmail(cfg, "subject", "body")
+	if err == nil || !strings.Contains(err.Error(), "ops@blocked.example") {
+		t.Errorf("err = %v, want the rejected recipient", err)
+	}
+}
+
+func TestSendEmailRequiresAddresses(t *testing.T) {
+	if err := SendEmail(rclone.EmailConfig{SMTP: "127.0.0.1", From: "mover@example.com"}, "subject", "body"); err == nil {
+		t.Error("expected an error without a recipient")
+	}
+}
@@ -0,0 +1,91 @@
+package rclone
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// maxConfigBackups is the number of rotating config backups kept
+const maxConfigBackups = 3
+
+// backupPath returns the path of the n-th backup (1 is the most recent)
+func (cm *ConfigManager) backupPath(n int) string {
+	return fmt.Sprintf("%s.bak.%d", cm.configPath, n)
+}
+
+// BackupConfig copies the current config to rclone.conf.bak.1, rotating
+// older backups up to rclone.conf.bak.3
+func (cm *ConfigManager) BackupConfig() error {
+	data, err := os.ReadFile(cm.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	// Don't let an empty config push out useful backups
+	if len(data) == 0 {
+		return nil
+	}
+
+	for n := maxConfigBackups - 1; n >= 1; n-- {
+		if _, err := os.Stat(cm.backupPath(n)); err == nil {
+			if err := os.Rename(cm.backupPath(n), cm.backupPath(n+1)); err != nil {
+				return fmt.Errorf("failed to rotate backup: %w", err)
+			}
+		}
+	}
+
+	if err := os.WriteFile(cm.backupPath(1), data, 0600); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreLatestBackup replaces the config with the most recent backup. The
+// current config is backed up first, so a restore can itself be undone.
+func (cm *ConfigManager) RestoreLatestBackup() error {
+	data, err := os.ReadFile(cm.backupPath(1))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no config backup available")
+		}
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	if err := cm.BackupConfig(); err != nil {
+		return err
+	}
+
+	tmpPath := cm.configPath + ".restore"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, cm.configPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to restore config: %w", err)
+	}
+
+	return nil
+}
+
+// restoreIfEmpty restores the latest backup when the config file is empty,
+// which usually means a previous write was interrupted
+func (cm *ConfigManager) restoreIfEmpty() {
+	info, err := os.Stat(cm.configPath)
+	if err != nil || info.Size() > 0 {
+		return
+	}
+
+	if _, err := os.Stat(cm.backupPath(1)); err != nil {
+		return
+	}
+
+	if err := cm.RestoreLatestBackup(); err != nil {
+		log.Printf("WARNING: rclone config is empty and restoring the backup failed: %v", err)
+		return
+	}
+
+	log.Printf("WARNING: rclone config %s was empty, restored from %s", cm.configPath, cm.backupPath(1))
+}
@@ -0,0 +1,148 @@
+package rclone
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readBackup(t *testing.T, cm *ConfigManager, n int) string {
+	t.Helper()
+	data, err := os.ReadFile(cm.backupPath(n))
+	if err != nil {
+		t.Fatalf("backup %d: %v", n, err)
+	}
+	return string(data)
+}
+
+func TestBackupConfigRotation(t *testing.T) {
+	cm := newTestConfigManager(t, "")
+
+	for _, version := range []string{"v1", "v2", "v3", "v4", "v5"} {
+		if err := os.WriteFile(cm.GetConfigPath(), []byte(version), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := cm.BackupConfig(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for n, want := range map[int]string{1: "v5", 2: "v4", 3: "v3"} {
+		if got := readBackup(t, cm, n); got != want {
+			t.Errorf("backup %d = %q, want %q", n, got, want)
+		}
+	}
+	if _, err := os.Stat(cm.backupPath(maxConfigBackups + 1)); !os.IsNotExist(err) {
+		t.Errorf("more than %d backups kept: %v", maxConfigBackups, err)
+	}
+}
+
+func TestBackupConfigSkipsEmptyConfig(t *testing.T) {
+	cm := newTestConfigManager(t, "v1")
+	if err := cm.BackupConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(cm.GetConfigPath(), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.BackupConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readBackup(t, cm, 1); got != "v1" {
+		t.Errorf("backup 1 = %q, want v1", got)
+	}
+	if _, err := os.Stat(cm.backupPath(2)); !os.IsNotExist(err) {
+		t.Errorf("empty config was rotated in: %v", err)
+	}
+}
+
+func TestRestoreLatestBackup(t *testing.T) {
+	cm := newTestConfigManager(t, "[good]\ntype = local\n")
+	if err := cm.BackupConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cm.GetConfigPath(), []byte("[broken]\ntype = local\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cm.RestoreLatestBackup(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readConfig(t, cm); got != "[good]\ntype = local\n" {
+		t.Errorf("config = %q, want the backup", got)
+	}
+	// The overwritten config is kept, so the restore can be undone
+	if got := readBackup(t, cm, 1); got != "[broken]\ntype = local\n" {
+		t.Errorf("backup 1 = %q, want the config before the restore", got)
+	}
+	if got := readBackup(t, cm, 2); got != "[good]\ntype = local\n" {
+		t.Errorf("backup 2 = %q, want the restored backup", got)
+	}
+}
+
+func TestRestoreLatestBackupWithoutBackup(t *testing.T) {
+	cm := newTestConfigManager(t, "[a]\ntype = local\n")
+	if err := cm.RestoreLatestBackup(); err == nil {
+		t.Error("expected an error without a backup")
+	}
+	if got := readConfig(t, cm); got != "[a]\ntype = local\n" {
+		t.Errorf("config changed to %q", got)
+	}
+}
+
+// An interrupted write leaves an empty config; the next start restores it
+func TestNewConfigManagerRestoresAfterPartialWrite(t *testing.T) {
+	cm := newTestConfigManager(t, "[site]\ntype = local\n")
+	if err := cm.BackupConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(cm.GetConfigPath(), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewConfigManager(filepath.Dir(cm.GetConfigPath()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.GetRemote("site"); err != nil {
+		t.Errorf("remote not restored: %v", err)
+	}
+}
+
+func TestAddAndDeleteRemoteSaveAtomically(t *testing.T) {
+	cm := newTestConfigManager(t, "[existing]\ntype = local\n")
+
+	if err := cm.AddRemote(Remote{Name: "site", Type: "local"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readBackup(t, cm, 1); got != "[existing]\ntype = local\n" {
+		t.Errorf("backup before add = %q", got)
+	}
+	if err := cm.DeleteRemote("existing"); err != nil {
+		t.Fatal(err)
+	}
+
+	config := readConfig(t, cm)
+	if !strings.Contains(config, "[site]") || strings.Contains(config, "[existing]") {
+		t.Errorf("config = %q", config)
+	}
+	if !strings.Contains(readBackup(t, cm, 1), "[existing]") {
+		t.Error("no backup before delete")
+	}
+
+	info, err := os.Stat(cm.GetConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config permissions = %o, want 600", perm)
+	}
+	leftovers, _ := filepath.Glob(cm.GetConfigPath() + ".tmp-*")
+	if len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
@@ -0,0 +1,43 @@
+package rclone
+
+import "time"
+
+// DestinationSpec is one destination of a batch migration
+type DestinationSpec struct {
+	Remote string `json:"remote"`
+	Path   string `json:"path"`
+}
+
+// BatchJob groups the migrations of one source to several destinations.
+// Each destination runs as its own MigrationJob.
+type BatchJob struct {
+	MasterID  string    `json:"master_id"`
+	SubJobIDs []string  `json:"sub_job_ids"`
+	StartTime time.Time `json:"start_time"`
+}
+
+// ForDestination returns the options of the migration to a single destination
+// of a batch
+func (o MigrationOptions) ForDestination(dest DestinationSpec) MigrationOptions {
+	o.DestRemote = dest.Remote
+	o.DestPath = dest.Path
+	o.DestRemotes = nil
+	return o
+}
+
+// BatchStatus aggregates the statuses of the sub-jobs of a batch: it is
+// running until every sub-job has finished, then failed if any of them did
+// not complete
+func BatchStatus(statuses []string) string {
+	status := "completed"
+	for _, s := range statuses {
+		switch s {
+		case "running":
+			return "running"
+		case "completed":
+		default:
+			status = "failed"
+		}
+	}
+	return status
+}
@@ -0,0 +1,50 @@
+package rclone
+
+import "testing"
+
+func TestBatchStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     string
+	}{
+		{"all completed", []string{"completed", "completed"}, "completed"},
+		{"one still running", []string{"completed", "running", "failed"}, "running"},
+		{"running before a failure", []string{"failed", "running"}, "running"},
+		{"one failed", []string{"completed", "failed"}, "failed"},
+		{"one cancelled", []string{"cancelled", "completed"}, "failed"},
+		{"dropped from history", []string{"completed", "unknown"}, "failed"},
+		{"no sub-jobs", nil, "completed"},
+	}
+	for _, tt := range tests {
+		if got := BatchStatus(tt.statuses); got != tt.want {
+			t.Errorf("%s: BatchStatus(%q) = %q, want %q", tt.name, tt.statuses, got, tt.want)
+		}
+	}
+}
+
+func TestForDestination(t *testing.T) {
+	opts := MigrationOptions{
+		SourceRemote: "old",
+		SourcePath:   "/var/www",
+		Transfers:    8,
+		DestRemotes: []DestinationSpec{
+			{Remote: "eu", Path: "/srv/www"},
+			{Remote: "us", Path: "/home/site"},
+		},
+	}
+
+	sub := opts.ForDestination(opts.DestRemotes[1])
+	if sub.DestRemote != "us" || sub.DestPath != "/home/site" {
+		t.Errorf("destination = %s:%s, want us:/home/site", sub.DestRemote, sub.DestPath)
+	}
+	if sub.DestRemotes != nil {
+		t.Errorf("sub-job keeps the batch destinations: %v", sub.DestRemotes)
+	}
+	if sub.SourceRemote != "old" || sub.SourcePath != "/var/www" || sub.Transfers != 8 {
+		t.Errorf("shared options not copied: %+v", sub)
+	}
+	if len(opts.DestRemotes) != 2 {
+		t.Error("batch options modified")
+	}
+}
@@ -0,0 +1,137 @@
+package rclone
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timetableDays are the day prefixes accepted in --bwlimit timetable entries
+var timetableDays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// ParseBandwidthLimit parses an rclone --bwlimit value into bytes per second.
+// Accepted forms follow rclone's syntax:
+//   - a single rate: "10M", "500k", "1G", "1.5MiB/s"; a value without a
+//     suffix is in KiB/s, like in rclone
+//   - separate upload and download rates: "10M:100k"
+//   - a timetable of "[Day-]HH:MM,rate" entries: "08:00,512k 12:00,off"
+//
+// "off", "0" and "" mean unlimited and return 0. For up:down limits and
+// timetables the highest rate is returned, or 0 if any part is unlimited.
+func ParseBandwidthLimit(s string) (int64, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return 0, nil
+	}
+
+	if !strings.Contains(value, ",") {
+		limit, err := parseUpDownLimit(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid bandwidth limit %q", s)
+		}
+		return limit, nil
+	}
+
+	var highest int64
+	for _, entry := range strings.Fields(value) {
+		limit, err := parseTimetableEntry(entry)
+		if err != nil {
+			return 0, fmt.Errorf("invalid bandwidth timetable entry %q: %w", entry, err)
+		}
+		if limit == 0 {
+			return 0, nil
+		}
+		highest = max(highest, limit)
+	}
+	return highest, nil
+}
+
+// parseTimetableEntry parses a "[Day-]HH:MM,rate" timetable entry
+func parseTimetableEntry(entry string) (int64, error) {
+	when, rate, ok := strings.Cut(entry, ",")
+	if !ok {
+		return 0, fmt.Errorf("expected [Day-]HH:MM,rate")
+	}
+
+	if day, clock, hasDay := strings.Cut(when, "-"); hasDay {
+		known := false
+		for _, d := range timetableDays {
+			known = known || strings.EqualFold(day, d)
+		}
+		if !known {
+			return 0, fmt.Errorf("unknown day %q", day)
+		}
+		when = clock
+	}
+	if _, err := time.Parse("15:04", when); err != nil {
+		return 0, fmt.Errorf("invalid time %q", when)
+	}
+
+	limit, err := parseUpDownLimit(rate)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q", rate)
+	}
+	return limit, nil
+}
+
+// parseUpDownLimit parses a rate or an "upload:download" pair of rates
+func parseUpDownLimit(value string) (int64, error) {
+	up, down, split := strings.Cut(value, ":")
+	upLimit, err := parseBandwidthRate(up)
+	if err != nil || !split {
+		return upLimit, err
+	}
+
+	downLimit, err := parseBandwidthRate(down)
+	if err != nil {
+		return 0, err
+	}
+	if upLimit == 0 || downLimit == 0 {
+		return 0, nil
+	}
+	return max(upLimit, downLimit), nil
+}
+
+// parseBandwidthRate parses a single rate such as "10M" or "off"
+func parseBandwidthRate(value string) (int64, error) {
+	if strings.EqualFold(value, "off") {
+		return 0, nil
+	}
+
+	// Binary and byte suffixes are optional: "1.5MiB/s", "1.5Mi" and "1.5M"
+	// are the same rate, while a lone "B" means bytes
+	value = strings.TrimSuffix(value, "/s")
+	if n := len(value); n > 1 && value[n-1] == 'B' && strings.ContainsRune("kKmMgGtTpPi", rune(value[n-2])) {
+		value = value[:n-1]
+	}
+	value = strings.TrimSuffix(value, "i")
+
+	multiplier := float64(1024) // rclone defaults to KiB/s
+	if n := len(value); n > 0 && (value[n-1] < '0' || value[n-1] > '9') {
+		switch value[n-1] {
+		case 'b', 'B':
+			multiplier = 1
+		case 'k', 'K':
+			multiplier = 1024
+		case 'm', 'M':
+			multiplier = 1024 * 1024
+		case 'g', 'G':
+			multiplier = 1024 * 1024 * 1024
+		case 't', 'T':
+			multiplier = 1024 * 1024 * 1024 * 1024
+		case 'p', 'P':
+			multiplier = 1024 * 1024 * 1024 * 1024 * 1024
+		default:
+			return 0, fmt.Errorf("unknown unit %q", value[n-1:])
+		}
+		value = value[:n-1]
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid rate %q", value)
+	}
+
+	return int64(number * multiplier), nil
+}
@@ -0,0 +1,71 @@
+package rclone
+
+import "testing"
+
+func TestParseBandwidthLimit(t *testing.T) {
+	const (
+		kib = 1024
+		mib = 1024 * 1024
+	)
+
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"", 0},
+		{"off", 0},
+		{"OFF", 0},
+		{"0", 0},
+		{"512", 512 * kib},
+		{"500b", 500},
+		{"500B", 500},
+		{"100k", 100 * kib},
+		{"10M", 10 * mib},
+		{"1.5MiB/s", 3 * mib / 2},
+		{"1G", 1024 * mib},
+		{"2KiB", 2 * kib},
+		{"3Gi", 3 * 1024 * mib},
+		{" 2M ", 2 * mib},
+
+		// Separate upload and download rates
+		{"10M:100k", 10 * mib},
+		{"100k:10M", 10 * mib},
+		{"10M:off", 0},
+
+		// Timetables
+		{"08:00,512k 12:00,10M", 10 * mib},
+		{"08:00,512k 12:00,off", 0},
+		{"Mon-00:00,512 Fri-23:59,10M:1M Sat-10:00,1M", 10 * mib},
+		{"sun-12:00,2M", 2 * mib},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBandwidthLimit(tt.value)
+		if err != nil {
+			t.Errorf("ParseBandwidthLimit(%q): unexpected error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBandwidthLimit(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseBandwidthLimitInvalid(t *testing.T) {
+	for _, value := range []string{
+		"fast",
+		"-1M",
+		"10X",
+		"10M 5M",
+		"10M:5M:1M",
+		"10M:",
+		"08:00,",
+		"25:00,1M",
+		"Someday-08:00,1M",
+		"08:00,1M 12:00",
+	} {
+		if limit, err := ParseBandwidthLimit(value); err == nil {
+			t.Errorf("ParseBandwidthLimit(%q) = %d, want an error", value, limit)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package rclone
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const combinedCheckOutput = `= index.php
+= wp-content/style.css
+* wp-config.php
++ wp-content/uploads/new.jpg
++ wp-content/uploads/with space.png
+- old-backup.zip
+! wp-content/cache/locked.tmp
+not a report line
+`
+
+func TestParseCheckOutput(t *testing.T) {
+	result := ParseCheckOutput(combinedCheckOutput)
+
+	want := &CheckResult{
+		Matched:        2,
+		Differ:         1,
+		MissingDest:    2,
+		ExtraDest:      1,
+		Errors:         1,
+		DifferingFiles: []string{"wp-config.php"},
+		MissingFiles:   []string{"wp-content/uploads/new.jpg", "wp-content/uploads/with space.png"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("ParseCheckOutput = %+v, want %+v", result, want)
+	}
+}
+
+func TestParseCheckOutputEmpty(t *testing.T) {
+	result := ParseCheckOutput("")
+	if result.Matched != 0 || result.DifferingFiles == nil || result.MissingFiles == nil {
+		t.Errorf("ParseCheckOutput(\"\") = %+v, want zero counts and empty lists", result)
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	logFile := fakeRcloneLog(t, `echo "= index.php"`)
+
+	result, err := NewExecutor("").RunCheck(context.Background(), MigrationOptions{
+		SourceRemote:      "src",
+		SourcePath:        "/var/www",
+		DestRemote:        "dst",
+		DestPath:          "/srv/www",
+		ExcludeExtensions: []string{".log"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Matched != 1 {
+		t.Errorf("result = %+v, want 1 match", result)
+	}
+
+	calls := readCalls(t, logFile)
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "check src:/var/www dst:/srv/www --combined - --exclude *.log") {
+		t.Errorf("calls = %q", calls)
+	}
+}
+
+// rclone check exits with status 1 when it finds differences
+func TestRunCheckDifferencesFound(t *testing.T) {
+	writeFakeRclone(t, `printf '= index.php\n* wp-config.php\n+ new.jpg\n'
+echo "2024/01/15 ERROR : 2 differences found" >&2
+exit 1`)
+
+	result, err := NewExecutor("").RunCheck(context.Background(), MigrationOptions{SourceRemote: "src", DestRemote: "dst"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Matched != 1 || result.Differ != 1 || result.MissingDest != 1 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestRunCheckFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"exit 1 without a report", "echo 'failed to connect' >&2; exit 1"},
+		{"other exit status", "echo '= index.php'; exit 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFakeRclone(t, tt.script)
+			if _, err := NewExecutor("").RunCheck(context.Background(), MigrationOptions{SourceRemote: "src", DestRemote: "dst"}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"time"
+)
+
+// partialFilePattern matches the temporary files rclone writes while a file
+// is being uploaded and renames once it is complete, such as
+// "index.php.1a2b3c4d.partial". Requiring the hash keeps plain "*.partial"
+// files that belong to the site out of the cleanup.
+const partialFilePattern = "*.*.partial"
+
+// CleanupResult reports the partial files removed after a failed migration
+type CleanupResult struct {
+	FilesDeleted int      `json:"files_deleted"`
+	BytesFreed   int64    `json:"bytes_freed"`
+	Errors       []string `json:"errors,omitempty"`
+}
+
+// CleanupPartialFiles deletes the partial uploads a failed or cancelled
+// migration left at its destination. Only files modified since the
+// migration started are considered.
+func (e *Executor) CleanupPartialFiles(ctx context.Context, opts MigrationOptions, startTime time.Time) *CleanupResult {
+	result := &CleanupResult{}
+
+	destPath := opts.DestPath
+	args := []string{
+		"--include", partialFilePattern,
+		"--max-age", startTime.UTC().Format(time.RFC3339),
+	}
+	if opts.SingleFileMode {
+		// Only look next to the destination file
+		destPath = path.Dir(destPath)
+		args = append(args, "--max-depth", "1")
+	}
+	target := fmt.Sprintf("%s:%s", opts.DestRemote, destPath)
+
+	size, err := e.size(ctx, append([]string{target}, args...))
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	if size.Count == 0 {
+		return result
+	}
+
+	cmd := exec.CommandContext(ctx, "rclone", append([]string{"delete", target}, args...)...)
+	if e.configPath != "" {
+		cmd.Args = append(cmd.Args, "--config", e.configPath)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("rclone delete failed: %v: %s", err, output))
+		return result
+	}
+
+	result.FilesDeleted = int(size.Count)
+	result.BytesFreed = size.Bytes
+	return result
+}
@@ -0,0 +1,81 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRcloneLog installs a fake rclone that appends its arguments to a log
+// file, runs script, and returns the path of the log
+func fakeRcloneLog(t *testing.T, script string) string {
+	t.Helper()
+	logFile := filepath.Join(t.TempDir(), "calls.log")
+	writeFakeRclone(t, `echo "$*" >> "`+logFile+`"
+`+script)
+	return logFile
+}
+
+func readCalls(t *testing.T, logFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestCleanupPartialFiles(t *testing.T) {
+	logFile := fakeRcloneLog(t, `case "$1" in
+  size) echo '{"count":2,"bytes":300}' ;;
+esac`)
+
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	result := NewExecutor("").CleanupPartialFiles(context.Background(), MigrationOptions{
+		DestRemote: "dst",
+		DestPath:   "/var/www",
+	}, start)
+
+	if len(result.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	if result.FilesDeleted != 2 || result.BytesFreed != 300 {
+		t.Errorf("result = %+v, want 2 files and 300 bytes", result)
+	}
+
+	calls := readCalls(t, logFile)
+	if len(calls) != 2 {
+		t.Fatalf("got calls %q, want size then delete", calls)
+	}
+	wantArgs := "dst:/var/www --include *.*.partial --max-age 2024-01-15T10:30:00Z"
+	if calls[0] != "size --json "+wantArgs {
+		t.Errorf("size call = %q", calls[0])
+	}
+	if calls[1] != "delete "+wantArgs {
+		t.Errorf("delete call = %q", calls[1])
+	}
+}
+
+func TestCleanupPartialFilesNothingToDelete(t *testing.T) {
+	logFile := fakeRcloneLog(t, `echo '{"count":0,"bytes":0}'`)
+
+	result := NewExecutor("").CleanupPartialFiles(context.Background(), MigrationOptions{
+		DestRemote:     "dst",
+		DestPath:       "/var/www/index.php",
+		SingleFileMode: true,
+	}, time.Now())
+
+	if result.FilesDeleted != 0 || len(result.Errors) > 0 {
+		t.Errorf("result = %+v, want nothing deleted", result)
+	}
+	calls := readCalls(t, logFile)
+	if len(calls) != 1 || !strings.HasPrefix(calls[0], "size --json dst:/var/www ") || !strings.HasSuffix(calls[0], "--max-depth 1") {
+		t.Errorf("calls = %q, want a single size call next to the file", calls)
+	}
+}
@@ -1,11 +1,18 @@
 package rclone
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"gopkg.in/ini.v1"
+
+	"github.com/gonzague/website-mover/backend/internal/sshutil"
 )
 
 // Remote represents an rclone remote configuration
@@ -17,12 +24,91 @@ type Remote struct {
 	Password string            `json:"password,omitempty"`
 	Port     int               `json:"port"`
 	KeyFile  string            `json:"key_file,omitempty"`
+	KeyPEM   string            `json:"key_pem,omitempty"` // inline private key, stored as a key file
 	Params   map[string]string `json:"params,omitempty"` // Additional parameters
 }
 
+var (
+	// ErrRemoteNotFound is returned when a remote does not exist in the config
+	ErrRemoteNotFound = errors.New("remote not found")
+
+	// ErrRemoteNameConflict is returned when a remote name collides with an existing one
+	ErrRemoteNameConflict = errors.New("remote name conflicts with an existing remote")
+
+	// ErrInvalidRemoteName is returned when a remote name contains unsupported characters
+	ErrInvalidRemoteName = errors.New("invalid remote name")
+)
+
+// remoteNamePattern restricts remote names to characters safe in rclone paths
+var remoteNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Validate checks that the remote has a valid name, a type and the
+// fields required by its protocol
+func (r Remote) Validate() error {
+	if !remoteNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("%w %q: only letters, digits, '_' and '-' are allowed", ErrInvalidRemoteName, r.Name)
+	}
+	if r.Type == "" {
+		return fmt.Errorf("remote type is required")
+	}
+
+	switch r.Type {
+	case "sftp", "ftp", "rsync":
+		if r.Host == "" {
+			return fmt.Errorf("host is required for %s remotes", r.Type)
+		}
+		if r.Type == "ftp" {
+			return validateFTPParams(r.Params)
+		}
+		if r.KeyPEM != "" {
+			if _, err := sshutil.ValidateSSHKey(r.KeyPEM); err != nil {
+				return fmt.Errorf("invalid key_pem: %w", err)
+			}
+		}
+	case "s3":
+		if r.Params["provider"] == "" {
+			return fmt.Errorf("provider is required for s3 remotes")
+		}
+	}
+
+	return nil
+}
+
+// validateFTPParams checks the FTPS and data connection params of an ftp remote.
+//
+// rclone supports explicit FTPS (explicit_tls, AUTH TLS on port 21) and
+// implicit FTPS (tls, TLS from the first byte, usually on port 990). Implicit
+// FTPS is deprecated but some shared hosts still only offer it. The two modes
+// are mutually exclusive. rclone's ftp backend always uses passive mode, so
+// active mode cannot be requested.
+func validateFTPParams(params map[string]string) error {
+	flags := make(map[string]bool)
+	for _, key := range []string{"explicit_tls", "tls", "passive"} {
+		value, ok := params[key]
+		if !ok || value == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", key, value)
+		}
+		flags[key] = enabled
+	}
+
+	if flags["explicit_tls"] && flags["tls"] {
+		return fmt.Errorf("explicit_tls and tls (implicit FTPS) cannot both be enabled")
+	}
+	if passive, ok := flags["passive"]; ok && !passive {
+		return fmt.Errorf("active FTP mode is not supported by rclone, only passive mode is available")
+	}
+
+	return nil
+}
+
 // ConfigManager manages rclone configuration
 type ConfigManager struct {
 	configPath string
+	keysDir    string // where inline SSH keys are stored
 }
 
 // NewConfigManager creates a new config manager
@@ -48,9 +134,18 @@ func NewConfigManager(configDir string) (*ConfigManager, error) {
 		}
 	}
 
-	return &ConfigManager{
+	keysDir := filepath.Join(configDir, "keys")
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		keysDir = filepath.Join(homeDir, ".config", "website-mover", "keys")
+	}
+
+	cm := &ConfigManager{
 		configPath: configPath,
-	}, nil
+		keysDir:    keysDir,
+	}
+	cm.restoreIfEmpty()
+
+	return cm, nil
 }
 
 // AddRemote adds or updates a remote configuration
@@ -67,6 +162,9 @@ func (cm *ConfigManager) AddRemote(remote Remote) error {
 	}
 
 	section.Key("type").SetValue(remote.Type)
+
+	// An inline key is staged in a temp file until the config is saved
+	var stagedKeyPath string
 	
 	// Handle type-specific fields
 	switch remote.Type {
@@ -91,7 +189,14 @@ func (cm *ConfigManager) AddRemote(remote Remote) error {
 			section.Key("pass").SetValue(obscured)
 		}
 		
-		if remote.KeyFile != "" {
+		if remote.KeyPEM != "" {
+			stagedKeyPath, err = cm.storeKeyPEM(remote.Name, remote.KeyPEM)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(stagedKeyPath)
+			section.Key("key_file").SetValue(cm.GeneratedKeyPath(remote.Name))
+		} else if remote.KeyFile != "" {
 			section.Key("key_file").SetValue(remote.KeyFile)
 		}
 	case "s3":
@@ -112,6 +217,10 @@ func (cm *ConfigManager) AddRemote(remote Remote) error {
 
 	// Add any additional parameters
 	for key, value := range remote.Params {
+		// passive is only validated, rclone's ftp backend has no such option
+		if remote.Type == "ftp" && key == "passive" {
+			continue
+		}
 		if value != "" {
 			// Handle password/secret obscuring
 			if key == "secret_access_key" || key == "pass" {
@@ -126,8 +235,18 @@ func (cm *ConfigManager) AddRemote(remote Remote) error {
 		}
 	}
 
-	if err := cfg.SaveTo(cm.configPath); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+	if err := cm.BackupConfig(); err != nil {
+		return err
+	}
+
+	if err := cm.saveAtomic(cfg); err != nil {
+		return err
+	}
+
+	if stagedKeyPath != "" {
+		if err := os.Rename(stagedKeyPath, cm.GeneratedKeyPath(remote.Name)); err != nil {
+			return fmt.Errorf("failed to install key file: %w", err)
+		}
 	}
 
 	return nil
@@ -140,9 +259,9 @@ func (cm *ConfigManager) GetRemote(name string) (*Remote, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	section := cfg.Section(name)
-	if section == nil {
-		return nil, fmt.Errorf("remote %s not found", name)
+	section, err := cfg.GetSection(name)
+	if err != nil || name == ini.DefaultSection {
+		return nil, fmt.Errorf("%w: %s", ErrRemoteNotFound, name)
 	}
 
 	remote := &Remote{
@@ -231,7 +350,230 @@ func (cm *ConfigManager) DeleteRemote(name string) error {
 
 	cfg.DeleteSection(name)
 
-	if err := cfg.SaveTo(cm.configPath); err != nil {
+	if err := cm.BackupConfig(); err != nil {
+		return err
+	}
+
+	if err := cm.saveAtomic(cfg); err != nil {
+		return err
+	}
+
+	if err := os.Remove(cm.GeneratedKeyPath(name)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove key file of remote %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// RemoteExists reports whether a remote with the given name exists,
+// comparing names case-insensitively
+func (cm *ConfigManager) RemoteExists(name string) (bool, error) {
+	cfg, err := ini.Load(cm.configPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	for _, section := range cfg.Sections() {
+		if section.Name() == ini.DefaultSection {
+			continue
+		}
+		if strings.EqualFold(section.Name(), name) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RenameRemote renames a remote, keeping all of its settings
+func (cm *ConfigManager) RenameRemote(oldName, newName string) error {
+	if !remoteNamePattern.MatchString(newName) {
+		return fmt.Errorf("%w %q: only letters, digits, '_' and '-' are allowed", ErrInvalidRemoteName, newName)
+	}
+
+	cfg, err := ini.Load(cm.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	oldSection, err := cfg.GetSection(oldName)
+	if err != nil || oldName == ini.DefaultSection {
+		return fmt.Errorf("%w: %s", ErrRemoteNotFound, oldName)
+	}
+
+	// The new name may only match the old one (e.g. a case-only rename)
+	for _, section := range cfg.Sections() {
+		name := section.Name()
+		if name == ini.DefaultSection || name == oldName {
+			continue
+		}
+		if strings.EqualFold(name, newName) {
+			return fmt.Errorf("%w: %s", ErrRemoteNameConflict, name)
+		}
+	}
+
+	newSection, err := cfg.NewSection(newName)
+	if err != nil {
+		return fmt.Errorf("failed to create section: %w", err)
+	}
+
+	// Copy raw values so obscured passwords stay untouched
+	for _, key := range oldSection.Keys() {
+		if _, err := newSection.NewKey(key.Name(), key.Value()); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", key.Name(), err)
+		}
+	}
+
+	// Inline keys are stored per remote name, so they follow the rename. The
+	// key is moved back if the config cannot be saved, so the saved config
+	// always points at an existing key.
+	oldKeyPath, newKeyPath := cm.GeneratedKeyPath(oldName), cm.GeneratedKeyPath(newName)
+	moveKey := oldSection.HasKey("key_file") && oldSection.Key("key_file").Value() == oldKeyPath
+	if moveKey {
+		if err := os.Rename(oldKeyPath, newKeyPath); err != nil {
+			return fmt.Errorf("failed to move key file: %w", err)
+		}
+		newSection.Key("key_file").SetValue(newKeyPath)
+	}
+
+	cfg.DeleteSection(oldName)
+
+	err = cm.BackupConfig()
+	if err == nil {
+		err = cm.saveAtomic(cfg)
+	}
+	if err != nil {
+		if moveKey {
+			if rollbackErr := os.Rename(newKeyPath, oldKeyPath); rollbackErr != nil {
+				log.Printf("Failed to move key file of remote %s back: %v", oldName, rollbackErr)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ImportError lists the sections that prevented an import
+type ImportError struct {
+	Errors []string
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("import failed for %d remote(s): %s", len(e.Errors), strings.Join(e.Errors, "; "))
+}
+
+// ImportFrom imports all remotes from an existing rclone config file.
+// The import is all-or-nothing: if any section is invalid, an *ImportError
+// is returned and the current config is left untouched.
+func (cm *ConfigManager) ImportFrom(path string) (int, error) {
+	src, err := ini.Load(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+
+	cfg, err := ini.Load(cm.configPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var importErrors []string
+	imported := 0
+
+	for _, section := range src.Sections() {
+		name := section.Name()
+		if name == ini.DefaultSection {
+			continue
+		}
+
+		// Read keys without Section.Key, which would create missing keys
+		remote := Remote{
+			Name:   name,
+			Params: make(map[string]string),
+		}
+		if section.HasKey("type") {
+			remote.Type = section.Key("type").String()
+		}
+		if section.HasKey("host") {
+			remote.Host = section.Key("host").String()
+		}
+		if section.HasKey("provider") {
+			remote.Params["provider"] = section.Key("provider").String()
+		}
+		if err := remote.Validate(); err != nil {
+			importErrors = append(importErrors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		// Existing remotes with the exact same name are replaced,
+		// case variants are rejected like in AddRemote
+		conflict := false
+		for _, existing := range cfg.Sections() {
+			if existing.Name() != name && strings.EqualFold(existing.Name(), name) {
+				importErrors = append(importErrors, fmt.Sprintf("%s: %v: %s", name, ErrRemoteNameConflict, existing.Name()))
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			continue
+		}
+
+		cfg.DeleteSection(name)
+		newSection, err := cfg.NewSection(name)
+		if err != nil {
+			importErrors = append(importErrors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		// Copy raw values: passwords in rclone.conf are already obscured
+		for _, key := range section.Keys() {
+			if _, err := newSection.NewKey(key.Name(), key.Value()); err != nil {
+				importErrors = append(importErrors, fmt.Sprintf("%s: failed to copy %s: %v", name, key.Name(), err))
+			}
+		}
+
+		imported++
+	}
+
+	if len(importErrors) > 0 {
+		return 0, &ImportError{Errors: importErrors}
+	}
+
+	if err := cm.BackupConfig(); err != nil {
+		return 0, err
+	}
+
+	if err := cm.saveAtomic(cfg); err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+// saveAtomic writes the config to a temp file and renames it over the
+// current config so a failed write never leaves a truncated file behind
+func (cm *ConfigManager) saveAtomic(cfg *ini.File) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(cm.configPath), "rclone.conf.tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp config: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := cfg.WriteTo(tmpFile); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temp config: %w", err)
+	}
+	if err := tmpFile.Chmod(0600); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to set config permissions: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write temp config: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, cm.configPath); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
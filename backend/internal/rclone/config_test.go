@@ -0,0 +1,305 @@
+package rclone
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestConfigManager returns a ConfigManager whose config and keys live in
+// temporary directories, with the given initial config
+func newTestConfigManager(t *testing.T, config string) *ConfigManager {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "rclone.conf"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cm, err := NewConfigManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cm
+}
+
+func readConfig(t *testing.T, cm *ConfigManager) string {
+	t.Helper()
+	data, err := os.ReadFile(cm.GetConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// writeKey stores a key file for name where inline keys are kept and returns
+// the config line pointing at it
+func writeKey(t *testing.T, cm *ConfigManager, name string) string {
+	t.Helper()
+	if err := os.MkdirAll(cm.keysDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cm.GeneratedKeyPath(name), []byte("key of "+name), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return "key_file = " + cm.GeneratedKeyPath(name) + "\n"
+}
+
+func TestRenameRemote(t *testing.T) {
+	cm := newTestConfigManager(t, "")
+	keyLine := writeKey(t, cm, "old")
+	if err := os.WriteFile(cm.GetConfigPath(), []byte("[old]\ntype = sftp\nhost = example.com\npass = obscured\n"+keyLine), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cm.RenameRemote("old", "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cm.GetRemote("old"); !errors.Is(err, ErrRemoteNotFound) {
+		t.Errorf("old remote still present: %v", err)
+	}
+	remote, err := cm.GetRemote("new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.Host != "example.com" || remote.KeyFile != cm.GeneratedKeyPath("new") {
+		t.Errorf("renamed remote = %+v", remote)
+	}
+	if config := readConfig(t, cm); !strings.Contains(strings.Join(strings.Fields(config), " "), "pass = obscured") {
+		t.Errorf("obscured password was not copied verbatim:\n%s", config)
+	}
+
+	if data, err := os.ReadFile(cm.GeneratedKeyPath("new")); err != nil || string(data) != "key of old" {
+		t.Errorf("key file not moved: %q, %v", data, err)
+	}
+	if _, err := os.Stat(cm.GeneratedKeyPath("old")); !os.IsNotExist(err) {
+		t.Errorf("old key file still present: %v", err)
+	}
+	if _, err := os.Stat(cm.backupPath(1)); err != nil {
+		t.Errorf("no backup written: %v", err)
+	}
+}
+
+func TestRenameRemoteCaseOnly(t *testing.T) {
+	cm := newTestConfigManager(t, "[site]\ntype = local\n")
+
+	if err := cm.RenameRemote("site", "Site"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.GetRemote("Site"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRenameRemoteErrors(t *testing.T) {
+	config := "[a]\ntype = local\n\n[Other]\ntype = local\n"
+
+	tests := []struct {
+		name    string
+		oldName string
+		newName string
+		want    error
+	}{
+		{"collision", "a", "Other", ErrRemoteNameConflict},
+		{"collision ignoring case", "a", "other", ErrRemoteNameConflict},
+		{"missing remote", "missing", "b", ErrRemoteNotFound},
+		{"invalid name", "a", "bad name", ErrInvalidRemoteName},
+	}
+
+	for _, tt := range tests {
+		cm := newTestConfigManager(t, config)
+		err := cm.RenameRemote(tt.oldName, tt.newName)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if got := readConfig(t, cm); got != config {
+			t.Errorf("%s: config changed to %q", tt.name, got)
+		}
+	}
+}
+
+func TestRenameRemoteRestoresKeyWhenSaveFails(t *testing.T) {
+	cm := newTestConfigManager(t, "")
+	config := "[old]\ntype = sftp\n" + writeKey(t, cm, "old")
+	if err := os.WriteFile(cm.GetConfigPath(), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	breakBackups(t, cm)
+
+	if err := cm.RenameRemote("old", "new"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if got := readConfig(t, cm); got != config {
+		t.Errorf("config changed to %q", got)
+	}
+	if _, err := os.Stat(cm.GeneratedKeyPath("old")); err != nil {
+		t.Errorf("key file not moved back: %v", err)
+	}
+	if _, err := os.Stat(cm.GeneratedKeyPath("new")); !os.IsNotExist(err) {
+		t.Errorf("key file left at the new name: %v", err)
+	}
+}
+
+// newKeyPEM returns a fresh ed25519 private key in OpenSSH PEM format
+func newKeyPEM(t *testing.T) string {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(block))
+}
+
+// breakBackups puts a directory in the way of the backup rotation, which
+// makes the next BackupConfig, and so the next save, fail
+func breakBackups(t *testing.T, cm *ConfigManager) {
+	t.Helper()
+	for n := 1; n < maxConfigBackups; n++ {
+		if err := os.WriteFile(cm.backupPath(n), []byte("previous"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(cm.backupPath(maxConfigBackups), "busy"), 0700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// keyFiles lists the files in the keys dir
+func keyFiles(t *testing.T, cm *ConfigManager) []string {
+	t.Helper()
+	entries, err := os.ReadDir(cm.keysDir)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestAddRemoteStoresKeyPEM(t *testing.T) {
+	cm := newTestConfigManager(t, "")
+	keyPEM := newKeyPEM(t)
+
+	if err := cm.AddRemote(Remote{Name: "web", Type: "sftp", Host: "example.com", User: "deploy", KeyPEM: keyPEM}); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath := cm.GeneratedKeyPath("web")
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != keyPEM {
+		t.Errorf("key file = %q, want the inline key", data)
+	}
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("key file mode = %o, want 600", mode)
+	}
+	if !strings.Contains(readConfig(t, cm), "key_file = "+keyPath) {
+		t.Errorf("config does not point at the key file:\n%s", readConfig(t, cm))
+	}
+	if files := keyFiles(t, cm); len(files) != 1 {
+		t.Errorf("keys dir = %v, want only web.pem", files)
+	}
+}
+
+func TestAddRemoteKeepsKeyWhenSaveFails(t *testing.T) {
+	cm := newTestConfigManager(t, "")
+	config := "[old]\ntype = sftp\n" + writeKey(t, cm, "old")
+	if err := os.WriteFile(cm.GetConfigPath(), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	breakBackups(t, cm)
+
+	// Updating a remote must not overwrite its current key
+	if err := cm.AddRemote(Remote{Name: "old", Type: "sftp", KeyPEM: newKeyPEM(t)}); err == nil {
+		t.Fatal("expected an error")
+	}
+	data, err := os.ReadFile(cm.GeneratedKeyPath("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "key of old" {
+		t.Errorf("key file overwritten with %q", data)
+	}
+
+	// Adding a remote must not leave its key behind
+	if err := cm.AddRemote(Remote{Name: "new", Type: "sftp", KeyPEM: newKeyPEM(t)}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := os.Stat(cm.GeneratedKeyPath("new")); !os.IsNotExist(err) {
+		t.Errorf("orphan key file left: %v", err)
+	}
+
+	if got := readConfig(t, cm); got != config {
+		t.Errorf("config changed to %q", got)
+	}
+	if files := keyFiles(t, cm); len(files) != 1 {
+		t.Errorf("keys dir = %v, want only old.pem", files)
+	}
+}
+
+func TestValidateFTPParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		valid  bool
+	}{
+		{"no params", nil, true},
+		{"explicit FTPS", map[string]string{"explicit_tls": "true"}, true},
+		{"implicit FTPS", map[string]string{"tls": "true", "port": "990"}, true},
+		{"explicit FTPS disabled with implicit", map[string]string{"explicit_tls": "false", "tls": "true"}, true},
+		{"empty values are ignored", map[string]string{"explicit_tls": "", "tls": ""}, true},
+		{"passive mode", map[string]string{"passive": "true"}, true},
+		{"both FTPS modes", map[string]string{"explicit_tls": "true", "tls": "1"}, false},
+		{"active mode", map[string]string{"passive": "false"}, false},
+		{"not a boolean", map[string]string{"tls": "yes"}, false},
+	}
+	for _, tt := range tests {
+		err := validateFTPParams(tt.params)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: validateFTPParams(%v) = %v, want valid %v", tt.name, tt.params, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateFTPRemote(t *testing.T) {
+	remote := Remote{Name: "shared-host", Type: "ftp", Host: "ftp.example.com", Params: map[string]string{"explicit_tls": "true", "tls": "true"}}
+	if err := remote.Validate(); err == nil {
+		t.Error("ftp remote with both FTPS modes accepted")
+	}
+
+	// The FTPS params are only checked on ftp remotes
+	remote.Type = "sftp"
+	if err := remote.Validate(); err != nil {
+		t.Errorf("sftp remote rejected: %v", err)
+	}
+
+	cm := newTestConfigManager(t, "")
+	ftps := Remote{Name: "shared-host", Type: "ftp", Host: "ftp.example.com", Params: map[string]string{"tls": "true"}}
+	if err := cm.AddRemote(ftps); err != nil {
+		t.Fatal(err)
+	}
+	if config := readConfig(t, cm); !strings.Contains(strings.Join(strings.Fields(config), " "), "tls = true") {
+		t.Errorf("implicit FTPS not written to the config:\n%s", config)
+	}
+}
@@ -0,0 +1,34 @@
+package rclone
+
+// Conflict resolution modes for files that already exist at the destination
+const (
+	ConflictOverwrite = "overwrite" // replace changed files (rclone default)
+	ConflictSkip      = "skip"      // never replace existing files
+	ConflictNewer     = "newer"     // replace only if the source is newer
+	ConflictLarger    = "larger"    // replace only if the source is larger; rclone has no equivalent
+	ConflictRename    = "rename"    // keep the replaced file as <name>.bak
+)
+
+// ConflictResolutionSupported reports whether mode can be run with rclone
+func ConflictResolutionSupported(mode string) bool {
+	switch mode {
+	case "", ConflictOverwrite, ConflictSkip, ConflictNewer, ConflictRename:
+		return true
+	}
+	return false
+}
+
+// conflictArgs returns the rclone flags implementing a conflict resolution mode
+func conflictArgs(mode string) []string {
+	switch mode {
+	case ConflictSkip:
+		return []string{"--ignore-existing"}
+	case ConflictNewer:
+		return []string{"--update"}
+	case ConflictRename:
+		// Without --backup-dir, --suffix keeps replaced and deleted files next
+		// to the originals
+		return []string{"--suffix", ".bak"}
+	}
+	return nil
+}
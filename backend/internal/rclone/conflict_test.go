@@ -0,0 +1,53 @@
+package rclone
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConflictArgs(t *testing.T) {
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{"", nil},
+		{ConflictOverwrite, nil},
+		{ConflictSkip, []string{"--ignore-existing"}},
+		{ConflictNewer, []string{"--update"}},
+		{ConflictRename, []string{"--suffix", ".bak"}},
+	}
+
+	for _, tt := range tests {
+		if got := conflictArgs(tt.mode); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("conflictArgs(%q) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestConflictResolutionSupported(t *testing.T) {
+	for _, mode := range []string{"", ConflictOverwrite, ConflictSkip, ConflictNewer, ConflictRename} {
+		if !ConflictResolutionSupported(mode) {
+			t.Errorf("ConflictResolutionSupported(%q) = false, want true", mode)
+		}
+	}
+	for _, mode := range []string{ConflictLarger, "merge"} {
+		if ConflictResolutionSupported(mode) {
+			t.Errorf("ConflictResolutionSupported(%q) = true, want false", mode)
+		}
+	}
+}
+
+func TestStartMigrationConflictFlags(t *testing.T) {
+	args := startMigrationArgs(t, MigrationOptions{ConflictResolution: ConflictNewer})
+	if !strings.Contains(args, " --update") {
+		t.Errorf("args %q do not contain --update", args)
+	}
+
+	args = startMigrationArgs(t, MigrationOptions{ConflictResolution: ConflictOverwrite})
+	for _, flag := range []string{"--update", "--ignore-existing", "--suffix"} {
+		if strings.Contains(args, flag) {
+			t.Errorf("overwrite args %q contain %s", args, flag)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package rclone
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DryRunReport represents the structured result of a dry-run migration
+type DryRunReport struct {
+	WouldTransfer      []FileItem `json:"would_transfer"`
+	WouldUpdate        []FileItem `json:"would_update"` // metadata only, e.g. modification time
+	WouldDelete        []FileItem `json:"would_delete"`
+	TotalBytesEstimate int64      `json:"total_bytes_estimate"`
+}
+
+// Diff actions, using unified diff style markers
+const (
+	DiffActionAdd    = "+"
+	DiffActionUpdate = "~"
+	DiffActionDelete = "-"
+)
+
+// DiffSummary counts the changes of a dry-run diff
+type DiffSummary struct {
+	Added             int   `json:"added"`
+	Updated           int   `json:"updated"`
+	Deleted           int   `json:"deleted"`
+	TotalAddedBytes   int64 `json:"total_added_bytes"`
+	TotalDeletedBytes int64 `json:"total_deleted_bytes"`
+}
+
+// DiffChange is a single file change of a dry-run diff
+type DiffChange struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+}
+
+// NewDryRunReport returns an empty report ready for AddLine
+func NewDryRunReport() *DryRunReport {
+	return &DryRunReport{
+		WouldTransfer: []FileItem{},
+		WouldUpdate:   []FileItem{},
+		WouldDelete:   []FileItem{},
+	}
+}
+
+// ParseDryRunOutput builds a DryRunReport from rclone dry-run output lines
+func ParseDryRunOutput(lines []string) *DryRunReport {
+	report := NewDryRunReport()
+	for _, line := range lines {
+		report.AddLine(line)
+	}
+	return report
+}
+
+// AddLine records a single rclone dry-run output line in the report
+//
+// rclone reports each skipped action as a NOTICE line, for example:
+// "2024/01/15 10:30:00 NOTICE: wp-content/uploads/image.jpg: Skipped copy as --dry-run is set (size 2.345Mi)"
+func (r *DryRunReport) AddLine(line string) {
+	idx := strings.Index(line, "NOTICE: ")
+	if idx == -1 {
+		return
+	}
+	rest := line[idx+len("NOTICE: "):]
+
+	// Split "path: message" on the last separator before the action
+	sep := strings.LastIndex(rest, ": Skipped ")
+	if sep == -1 {
+		return
+	}
+	path := strings.TrimSpace(rest[:sep])
+	message := rest[sep+2:]
+	if path == "" {
+		return
+	}
+
+	item := FileItem{
+		Name: path,
+		Size: parseDryRunSize(message),
+	}
+
+	if strings.Contains(message, "Skipped delete") || strings.Contains(message, "(delete)") {
+		r.WouldDelete = append(r.WouldDelete, item)
+	} else if strings.Contains(message, "Skipped update") || strings.Contains(message, "Skipped set") {
+		r.WouldUpdate = append(r.WouldUpdate, item)
+	} else {
+		r.WouldTransfer = append(r.WouldTransfer, item)
+		r.TotalBytesEstimate += item.Size
+	}
+}
+
+// parseDryRunSize extracts the size from a "(size 2.345Mi)" suffix
+func parseDryRunSize(message string) int64 {
+	idx := strings.Index(message, "(size ")
+	if idx == -1 {
+		return 0
+	}
+	sizeStr := message[idx+len("(size "):]
+	if end := strings.Index(sizeStr, ")"); end != -1 {
+		sizeStr = sizeStr[:end]
+	}
+	sizeStr = strings.TrimSpace(sizeStr)
+
+	// rclone prints binary suffixes without the trailing "B" (e.g. "2.345Mi")
+	split := strings.IndexFunc(sizeStr, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if split == -1 {
+		return parseSizeString(sizeStr)
+	}
+
+	unit := sizeStr[split:]
+	if !strings.HasSuffix(unit, "B") {
+		unit += "B"
+	}
+	return parseSizeString(sizeStr[:split] + " " + unit)
+}
+
+// Diff returns the report as a summary and a list of changes sorted by
+// directory, then path. rclone logs new and modified files the same way, so
+// both are reported as additions; updates are metadata-only changes.
+func (r *DryRunReport) Diff() (DiffSummary, []DiffChange) {
+	var summary DiffSummary
+	changes := make([]DiffChange, 0, len(r.WouldTransfer)+len(r.WouldUpdate)+len(r.WouldDelete))
+
+	for _, item := range r.WouldTransfer {
+		summary.Added++
+		summary.TotalAddedBytes += item.Size
+		changes = append(changes, DiffChange{Action: DiffActionAdd, Path: item.Name, Size: item.Size})
+	}
+	for _, item := range r.WouldUpdate {
+		summary.Updated++
+		changes = append(changes, DiffChange{Action: DiffActionUpdate, Path: item.Name, Size: item.Size})
+	}
+	for _, item := range r.WouldDelete {
+		summary.Deleted++
+		summary.TotalDeletedBytes += item.Size
+		changes = append(changes, DiffChange{Action: DiffActionDelete, Path: item.Name, Size: item.Size})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		dirI, dirJ := path.Dir(changes[i].Path), path.Dir(changes[j].Path)
+		if dirI != dirJ {
+			return dirI < dirJ
+		}
+		return changes[i].Path < changes[j].Path
+	})
+
+	return summary, changes
+}
+
+// FormatDiff renders changes as unified diff style text, with a "@@ dir @@"
+// header before each directory's files
+func FormatDiff(changes []DiffChange) string {
+	var out strings.Builder
+	currentDir := ""
+	for i, change := range changes {
+		dir := path.Dir(change.Path)
+		if i == 0 || dir != currentDir {
+			currentDir = dir
+			if dir == "." {
+				dir = "/"
+			}
+			fmt.Fprintf(&out, "@@ %s @@\n", dir)
+		}
+		if change.Action == DiffActionUpdate {
+			fmt.Fprintf(&out, "%s %s\n", change.Action, change.Path)
+		} else {
+			fmt.Fprintf(&out, "%s %s (%s)\n", change.Action, change.Path, FormatBytes(change.Size))
+		}
+	}
+	return out.String()
+}
+
+// FormatBytes formats a byte count with decimal units, e.g. "2.3 MB"
+func FormatBytes(bytes int64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "kMGTPE"[exp])
+}
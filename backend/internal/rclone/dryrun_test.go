@@ -0,0 +1,248 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFakeRclone puts an "rclone" shell script running script first in PATH
+func writeFakeRclone(t *testing.T, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	content := "#!/bin/sh\n" + script + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "rclone"), []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestParseDryRunOutput(t *testing.T) {
+	lines := []string{
+		"2024/01/15 10:30:00 INFO  : Starting dry run",
+		"2024/01/15 10:30:00 NOTICE: wp-content/uploads/image.jpg: Skipped copy as --dry-run is set (size 2Mi)",
+		"2024/01/15 10:30:00 NOTICE: index.php: Skipped copy as --dry-run is set (size 512)",
+		"2024/01/15 10:30:01 NOTICE: old/cache.tmp: Skipped delete as --dry-run is set (size 1Ki)",
+		"2024/01/15 10:30:01 NOTICE: removed.txt: Skipped remove (delete) as --dry-run is set",
+		"2024/01/15 10:30:01 NOTICE: style.css: Skipped update modification time as --dry-run is set (size 10)",
+		"2024/01/15 10:30:01 NOTICE: robots.txt: Skipped set modification time as --dry-run is set",
+		"2024/01/15 10:30:02 NOTICE: not a file action",
+		"Transferred:   0 B / 0 B, -, 0 B/s, ETA -",
+	}
+
+	report := ParseDryRunOutput(lines)
+
+	assertNames(t, "transfer", report.WouldTransfer, "wp-content/uploads/image.jpg", "index.php")
+	assertNames(t, "delete", report.WouldDelete, "old/cache.tmp", "removed.txt")
+	assertNames(t, "update", report.WouldUpdate, "style.css", "robots.txt")
+
+	if report.WouldTransfer[0].Size != 2*1024*1024 {
+		t.Errorf("image.jpg size = %d, want %d", report.WouldTransfer[0].Size, 2*1024*1024)
+	}
+	if report.WouldDelete[0].Size != 1024 {
+		t.Errorf("cache.tmp size = %d, want 1024", report.WouldDelete[0].Size)
+	}
+	if want := int64(2*1024*1024 + 512); report.TotalBytesEstimate != want {
+		t.Errorf("TotalBytesEstimate = %d, want %d", report.TotalBytesEstimate, want)
+	}
+}
+
+func TestParseDryRunOutputPathWithColon(t *testing.T) {
+	report := ParseDryRunOutput([]string{
+		"NOTICE: backups/db: 2024: Skipped copy as --dry-run is set (size 3)",
+	})
+	assertNames(t, "transfer", report.WouldTransfer, "backups/db: 2024")
+}
+
+func TestParseDryRunSize(t *testing.T) {
+	tests := []struct {
+		message string
+		want    int64
+	}{
+		{"Skipped copy as --dry-run is set (size 123)", 123},
+		{"Skipped copy as --dry-run is set (size 0)", 0},
+		{"Skipped copy as --dry-run is set (size 1Ki)", 1024},
+		{"Skipped copy as --dry-run is set (size 2.5Ki)", 2560},
+		{"Skipped copy as --dry-run is set (size 1.5Mi)", 1536 * 1024},
+		{"Skipped copy as --dry-run is set (size 1Gi)", 1024 * 1024 * 1024},
+		{"Skipped copy as --dry-run is set (size 1Ti)", 1024 * 1024 * 1024 * 1024},
+		{"Skipped copy as --dry-run is set (size 4KiB)", 4096},
+		{"Skipped copy as --dry-run is set", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseDryRunSize(tt.message); got != tt.want {
+			t.Errorf("parseDryRunSize(%q) = %d, want %d", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestParseDryRunOutputManyLines(t *testing.T) {
+	lines := make([]string, 0, 2500)
+	for i := 0; i < 2500; i++ {
+		lines = append(lines, fmt.Sprintf("NOTICE: file%04d.txt: Skipped copy as --dry-run is set (size 1)", i))
+	}
+
+	report := ParseDryRunOutput(lines)
+	if len(report.WouldTransfer) != 2500 {
+		t.Errorf("got %d transfers, want 2500", len(report.WouldTransfer))
+	}
+	if report.TotalBytesEstimate != 2500 {
+		t.Errorf("TotalBytesEstimate = %d, want 2500", report.TotalBytesEstimate)
+	}
+}
+
+// The output buffer keeps only the last maxOutputLines lines, but the report
+// of a dry-run job must include every file rclone reported
+func TestStartMigrationDryRunReportNotTruncated(t *testing.T) {
+	const files = 2500
+	writeFakeRclone(t, fmt.Sprintf(`i=0
+while [ $i -lt %d ]; do
+  echo "2024/01/15 10:30:00 NOTICE: dir/file$i.txt: Skipped copy as --dry-run is set (size 1Ki)" >&2
+  i=$((i+1))
+done
+echo "2024/01/15 10:30:01 NOTICE: config/site_secret: Skipped copy as --dry-run is set (size 1)" >&2
+echo "2024/01/15 10:30:01 NOTICE: stale.txt: Skipped delete as --dry-run is set (size 1)"`, files))
+
+	executor := NewExecutor("")
+	job, err := executor.StartMigration(context.Background(), MigrationOptions{
+		SourceRemote: "src",
+		SourcePath:   "/",
+		DestRemote:   "dst",
+		DestPath:     "/",
+		DryRun:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !job.WaitForCompletion(30 * time.Second) {
+		t.Fatal("job did not complete")
+	}
+	if job.GetStatus() != "completed" {
+		t.Fatalf("status = %s, want completed", job.GetStatus())
+	}
+
+	if n := len(job.GetOutput()); n > maxOutputLines {
+		t.Errorf("output buffer has %d lines, want at most %d", n, maxOutputLines)
+	}
+
+	report := job.GetDryRunReport()
+	if report == nil {
+		t.Fatal("no dry-run report")
+	}
+	if got := len(report.WouldTransfer); got != files+1 {
+		t.Errorf("got %d transfers, want %d", got, files+1)
+	}
+	if want := int64(files*1024 + 1); report.TotalBytesEstimate != want {
+		t.Errorf("TotalBytesEstimate = %d, want %d", report.TotalBytesEstimate, want)
+	}
+	assertNames(t, "delete", report.WouldDelete, "stale.txt")
+}
+
+func assertNames(t *testing.T, kind string, items []FileItem, want ...string) {
+	t.Helper()
+	got := make([]string, len(items))
+	for i, item := range items {
+		got[i] = item.Name
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s = %q, want %q", kind, got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	report := &DryRunReport{
+		WouldTransfer: []FileItem{
+			{Name: "wp-content/uploads/b.jpg", Size: 2000},
+			{Name: "index.php", Size: 100},
+			{Name: "wp-content/uploads/a.jpg", Size: 1000},
+		},
+		WouldUpdate: []FileItem{
+			{Name: "wp-content/style.css", Size: 50},
+		},
+		WouldDelete: []FileItem{
+			{Name: "wp-content/uploads/old.jpg", Size: 300},
+			{Name: "cache.tmp", Size: 7},
+		},
+	}
+
+	summary, changes := report.Diff()
+
+	wantSummary := DiffSummary{Added: 3, Updated: 1, Deleted: 2, TotalAddedBytes: 3100, TotalDeletedBytes: 307}
+	if summary != wantSummary {
+		t.Errorf("summary = %+v, want %+v", summary, wantSummary)
+	}
+
+	want := []DiffChange{
+		{Action: DiffActionDelete, Path: "cache.tmp", Size: 7},
+		{Action: DiffActionAdd, Path: "index.php", Size: 100},
+		{Action: DiffActionUpdate, Path: "wp-content/style.css", Size: 50},
+		{Action: DiffActionAdd, Path: "wp-content/uploads/a.jpg", Size: 1000},
+		{Action: DiffActionAdd, Path: "wp-content/uploads/b.jpg", Size: 2000},
+		{Action: DiffActionDelete, Path: "wp-content/uploads/old.jpg", Size: 300},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestDiffEmptyReport(t *testing.T) {
+	summary, changes := NewDryRunReport().Diff()
+	if summary != (DiffSummary{}) || len(changes) != 0 {
+		t.Errorf("got %+v, %+v; want an empty diff", summary, changes)
+	}
+	if out := FormatDiff(changes); out != "" {
+		t.Errorf("FormatDiff of no changes = %q, want empty", out)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	changes := []DiffChange{
+		{Action: DiffActionDelete, Path: "cache.tmp", Size: 7},
+		{Action: DiffActionAdd, Path: "index.php", Size: 1500},
+		{Action: DiffActionUpdate, Path: "wp-content/style.css", Size: 50},
+		{Action: DiffActionAdd, Path: "wp-content/uploads/a.jpg", Size: 2_300_000},
+		{Action: DiffActionDelete, Path: "wp-content/uploads/old.jpg", Size: 3_000_000_000},
+	}
+
+	want := `@@ / @@
+- cache.tmp (7 B)
++ index.php (1.5 kB)
+@@ wp-content @@
+~ wp-content/style.css
+@@ wp-content/uploads @@
++ wp-content/uploads/a.jpg (2.3 MB)
+- wp-content/uploads/old.jpg (3.0 GB)
+`
+	if got := FormatDiff(changes); got != want {
+		t.Errorf("FormatDiff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{999, "999 B"},
+		{1000, "1.0 kB"},
+		{1_500_000, "1.5 MB"},
+		{2_000_000_000_000, "2.0 TB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.bytes); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
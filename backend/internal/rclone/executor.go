@@ -4,10 +4,16 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+	"github.com/gonzague/website-mover/backend/internal/util"
 )
 
 // buildDisplayCommand creates a properly quoted command string for display/copy-paste
@@ -44,13 +50,77 @@ type MigrationOptions struct {
 	BandwidthLimit    string   `json:"bandwidth_limit,omitempty"`
 	DryRun            bool     `json:"dry_run"`
 	DeleteExtraneous  bool     `json:"delete_extraneous"` // sync instead of copy
+
+	// Extension filters, e.g. [".jpg", ".png"]; directories are always traversed
+	IncludeExtensions []string `json:"include_extensions,omitempty"`
+	ExcludeExtensions []string `json:"exclude_extensions,omitempty"`
+
+	// Only copy files modified after this time (incremental migration)
+	IncrementalSince *time.Time `json:"incremental_since,omitempty"`
+
+	// Skip files modified within this duration (e.g. "1h"), as they may still be being written
+	ExcludeModifiedWithin string `json:"exclude_modified_within,omitempty"`
+
+	// Send an email when the migration finishes
+	EmailConfig *EmailConfig `json:"email_config,omitempty"`
+
+	// Destinations of a batch migration; DestRemote and DestPath are ignored when set
+	DestRemotes []DestinationSpec `json:"dest_remotes,omitempty"`
+
+	// Copy a single file: SourcePath and DestPath are file paths (rclone copyto)
+	SingleFileMode bool `json:"single_file_mode,omitempty"`
+
+	// Pass --ignore-errors, so that a sync still deletes extraneous files when
+	// some transfers fail (rclone copy already carries on past failed files)
+	IgnoreErrors bool `json:"ignore_errors,omitempty"`
+
+	// What to do with files that already exist at the destination (Conflict* modes)
+	ConflictResolution string `json:"conflict_resolution,omitempty"`
+
+	// Delete the partial uploads left at the destination if the migration
+	// fails or is cancelled
+	CleanupOnFailure bool `json:"cleanup_on_failure,omitempty"`
+
+	// Size limits checked with rclone size before starting (0 = no limit).
+	// An exceeded limit refuses the migration when its *Check flag is set,
+	// otherwise it is only reported.
+	MaxFiles      int   `json:"max_files,omitempty"`
+	MaxTotalBytes int64 `json:"max_total_bytes,omitempty"`
+	MaxFilesCheck bool  `json:"max_files_check,omitempty"`
+	MaxBytesCheck bool  `json:"max_bytes_check,omitempty"`
+}
+
+// EmailConfig holds the SMTP settings for migration notifications
+type EmailConfig struct {
+	SMTP     string `json:"smtp"`
+	Port     int    `json:"port"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	TLS      bool   `json:"tls"` // implicit TLS (usually port 465); otherwise STARTTLS is used when offered
+}
+
+// WithoutSecrets returns a copy of the options safe to store or display
+func (o MigrationOptions) WithoutSecrets() MigrationOptions {
+	if o.EmailConfig != nil {
+		email := *o.EmailConfig
+		email.Password = ""
+		o.EmailConfig = &email
+	}
+	return o
 }
 
 // JobStats represents live migration statistics
 type JobStats struct {
-	TotalBytes    int64  `json:"total_bytes"`
-	TotalFiles    int64  `json:"total_files"`
-	TransferSpeed string `json:"transfer_speed"`
+	TotalBytes      int64   `json:"total_bytes"`
+	TotalFiles      int64   `json:"total_files"`
+	TransferSpeed   string  `json:"transfer_speed"`
+	PercentComplete float64 `json:"percent_complete"`
+	ETASeconds      int64   `json:"eta_seconds"`
+	ChecksDone      int64   `json:"checks_done"`
+	ChecksTotal     int64   `json:"checks_total"`
+	CurrentFile     string  `json:"current_file,omitempty"`
 }
 
 // StreamEvent represents an event in the migration stream
@@ -66,25 +136,89 @@ type MigrationJob struct {
 	Options     MigrationOptions `json:"options"`
 	Command     string    `json:"command"`
 	StartTime   time.Time `json:"start_time"`
-	Status      string    `json:"status"` // running, completed, failed
+	Status      string    `json:"status"` // running, completed, failed; see GetStatus
+	statusMux   sync.RWMutex
 	Output      []string  `json:"-"`
 	outputMux   sync.RWMutex
 	subscribers []chan StreamEvent
 	subMux      sync.RWMutex
 	
 	// Live Stats
-	Stats JobStats
+	Stats    JobStats
+	statsMux sync.RWMutex
+
+	// DryRunReport is populated once a dry-run job completes; see GetDryRunReport
+	DryRunReport *DryRunReport `json:"dry_run_report,omitempty"`
+
+	// Report built from every output line while a dry run is in progress,
+	// since the output buffer only keeps the most recent lines
+	dryRun    *DryRunReport
+	dryRunMux sync.Mutex
+
+	// ID of the history entry this job replays, if any
+	ReplayOfID string `json:"replay_of_id,omitempty"`
+
+	// ID of the failed history entry this job retries, if any, the original
+	// migration of the chain of retries, and how many times that original
+	// has been retried including this one
+	RetryOfID   string `json:"retry_of_id,omitempty"`
+	RetryRootID string `json:"retry_root_id,omitempty"`
+	RetryCount  int    `json:"retry_count,omitempty"`
+
+	// ID of the batch this job belongs to, if any
+	BatchID string `json:"batch_id,omitempty"`
+
+	// Size of the migration compared with its limits, when it has any
+	SizeCheck *LimitCheck `json:"size_check,omitempty"`
+
+	// Who started the job, and the X-Request-ID of the request that did
+	ClientIP  string `json:"client_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Cancellation and completion
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// Mask secrets in output lines before storing them
+	sanitize bool
+
+	// Heap size in bytes above which the output buffer is shrunk (0 = no limit)
+	memoryLimit uint64
+	linesAdded  int
+	lowMemory   bool
 }
 
+// cancelGracePeriod is how long rclone gets to exit after SIGINT before it is killed
+const cancelGracePeriod = 10 * time.Second
+
+// Output buffer sizes: the normal size and the size used when the process
+// approaches its memory limit
+const (
+	maxOutputLines          = 1000
+	lowMemoryMaxOutputLines = 100
+
+	// Memory usage is sampled every memoryCheckInterval output lines
+	memoryCheckInterval = 1000
+)
+
 // Executor handles rclone command execution
 type Executor struct {
 	configPath string
+
+	// SanitizationEnabled masks passwords and secrets in job commands and output
+	SanitizationEnabled bool
+
+	// MemoryLimitMB makes jobs keep less output once the heap grows close to it
+	// (0 = no limit)
+	MemoryLimitMB int
 }
 
 // NewExecutor creates a new executor
 func NewExecutor(configPath string) *Executor {
 	return &Executor{
-		configPath: configPath,
+		configPath:          configPath,
+		SanitizationEnabled: true,
 	}
 }
 
@@ -135,6 +269,11 @@ type FileItem struct {
 	Name  string `json:"name"`
 	IsDir bool   `json:"is_dir"`
 	Size  int64  `json:"size"`
+
+	// Only filled by ListPathRich
+	ModTime  time.Time `json:"mod_time,omitempty"`
+	MimeType string    `json:"mime_type,omitempty"`
+	ID       string    `json:"id,omitempty"`
 }
 
 // ListPath lists contents of a remote path
@@ -187,13 +326,55 @@ func (e *Executor) ListPath(ctx context.Context, remoteName, path string) ([]Fil
 	return items, nil
 }
 
+// CountFiles returns the number of files under a remote path, limited to files
+// modified after since when it is set
+func (e *Executor) CountFiles(ctx context.Context, remoteName, path string, since *time.Time) (int64, error) {
+	args := []string{fmt.Sprintf("%s:%s", remoteName, path)}
+	if since != nil {
+		args = append(args, "--max-age", since.UTC().Format(time.RFC3339))
+	}
+
+	size, err := e.size(ctx, args)
+	if err != nil {
+		return 0, err
+	}
+
+	return size.Count, nil
+}
+
+// filterArgs returns the rclone exclude and include flags for the options
+func filterArgs(opts MigrationOptions) []string {
+	args := []string{}
+
+	// Excludes
+	for _, exclude := range opts.Excludes {
+		args = append(args, "--exclude", exclude)
+	}
+	for _, ext := range opts.ExcludeExtensions {
+		args = append(args, "--exclude", "*"+ext)
+	}
+
+	// Includes use --filter rules: rclone evaluates --include before --exclude,
+	// which would let an included extension override the excludes above
+	if len(opts.IncludeExtensions) > 0 {
+		for _, ext := range opts.IncludeExtensions {
+			args = append(args, "--filter", "+ *"+ext)
+		}
+		args = append(args, "--filter", "- **")
+	}
+
+	return args
+}
+
 // StartMigration starts a migration job
 func (e *Executor) StartMigration(ctx context.Context, opts MigrationOptions) (*MigrationJob, error) {
 	// Build rclone command
 	cmdParts := []string{"rclone"}
 	
-	// Use sync if delete_extraneous, otherwise copy
-	if opts.DeleteExtraneous {
+	// Use copyto for a single file, sync if delete_extraneous, otherwise copy
+	if opts.SingleFileMode {
+		cmdParts = append(cmdParts, "copyto")
+	} else if opts.DeleteExtraneous {
 		cmdParts = append(cmdParts, "sync")
 	} else {
 		cmdParts = append(cmdParts, "copy")
@@ -219,30 +400,57 @@ func (e *Executor) StartMigration(ctx context.Context, opts MigrationOptions) (*
 	if opts.DryRun {
 		cmdParts = append(cmdParts, "--dry-run")
 	}
+	if opts.IncrementalSince != nil {
+		cmdParts = append(cmdParts, "--max-age", opts.IncrementalSince.UTC().Format(time.RFC3339))
+	}
+	if opts.ExcludeModifiedWithin != "" {
+		// --min-age only transfers files older than the given age; the value is
+		// passed in seconds since rclone's duration syntax differs from Go's
+		if within, err := time.ParseDuration(opts.ExcludeModifiedWithin); err == nil && within > 0 {
+			cmdParts = append(cmdParts, fmt.Sprintf("--min-age=%ds", int64(within.Seconds())))
+		}
+	}
 
-	// Excludes
-	for _, exclude := range opts.Excludes {
-		cmdParts = append(cmdParts, "--exclude", exclude)
+	if opts.IgnoreErrors {
+		cmdParts = append(cmdParts, "--ignore-errors")
 	}
 
+	cmdParts = append(cmdParts, conflictArgs(opts.ConflictResolution)...)
+	cmdParts = append(cmdParts, filterArgs(opts)...)
+
 	if e.configPath != "" {
 		cmdParts = append(cmdParts, "--config", e.configPath)
 	}
 
 	// Create job with properly quoted command string for display
 	displayCmd := buildDisplayCommand(cmdParts)
+	if e.SanitizationEnabled {
+		displayCmd = SanitizeCommand(displayCmd)
+	}
 	job := &MigrationJob{
-		ID:          fmt.Sprintf("mig-%d", time.Now().Unix()),
+		ID:          util.GenerateJobID(constants.MigrationJobIDPrefix),
 		Options:     opts,
 		Command:     displayCmd,
 		StartTime:   time.Now(),
 		Status:      "running",
 		Output:      []string{},
 		subscribers: []chan StreamEvent{},
+		done:        make(chan struct{}),
+		sanitize:    e.SanitizationEnabled,
+		memoryLimit: uint64(e.MemoryLimitMB) * 1024 * 1024,
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	job.cancel = cancel
+
 	// Start command
 	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
+	// exec.CommandContext kills with SIGKILL by default; send SIGINT instead so
+	// rclone can stop cleanly and print its final stats
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = cancelGracePeriod
 	
 	// Log command being executed
 	job.addOutput(fmt.Sprintf("Executing: %s", displayCmd))
@@ -260,54 +468,111 @@ func (e *Executor) StartMigration(ctx context.Context, opts MigrationOptions) (*
 	}
 
 	if err := cmd.Start(); err != nil {
+		cancel()
 		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
 	
 	job.addOutput(fmt.Sprintf("Process started with PID: %d", cmd.Process.Pid))
 
-	// Read output in goroutine
+	if opts.DryRun {
+		job.dryRun = NewDryRunReport()
+	}
+
+	// Read output in goroutines; cmd.Wait closes the pipes, so it must only
+	// be called once both readers are done
+	var readers sync.WaitGroup
+	readers.Add(2)
 	go func() {
+		defer readers.Done()
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			line := scanner.Text()
+			job.recordDryRunLine(line)
 			job.addOutput(line)
 			job.parseStats(line)
 		}
 	}()
 
 	go func() {
+		defer readers.Done()
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
 			line := scanner.Text()
+			job.recordDryRunLine(line)
 			job.addOutput(line)
-			// Rclone sends some info to stderr too
+			// Rclone logs periodic stats to stderr when using -v
+			job.parseStats(line)
 		}
 	}()
 
 	// Wait for completion in goroutine
 	go func() {
+		readers.Wait()
 		err := cmd.Wait()
-		if err != nil {
-			job.Status = "failed"
+		if opts.DryRun {
+			job.DryRunReport = job.dryRun
+		}
+		if ctx.Err() != nil {
+			job.setStatus("cancelled")
+			job.addOutput("Migration cancelled")
+		} else if err != nil {
+			job.setStatus("failed")
 			job.addOutput(fmt.Sprintf("ERROR: %v", err))
 		} else {
-			job.Status = "completed"
+			job.setStatus("completed")
 			job.addOutput("Migration completed successfully")
 		}
+		cancel()
 		job.closeSubscribers()
+		close(job.done)
 	}()
 
 	return job, nil
 }
 
+// Cancel stops the migration by sending SIGINT to rclone
+func (j *MigrationJob) Cancel() {
+	if j.cancel != nil {
+		j.cancel()
+	}
+}
+
+// Done returns a channel that is closed once the migration has finished
+func (j *MigrationJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// WaitForCompletion waits for the migration to finish, returning false on timeout
+func (j *MigrationJob) WaitForCompletion(timeout time.Duration) bool {
+	select {
+	case <-j.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // addOutput adds a line to the job output and notifies subscribers
 func (j *MigrationJob) addOutput(line string) {
+	if j.sanitize {
+		line = SanitizeOutputLine(line)
+	}
+
 	j.outputMux.Lock()
 	j.Output = append(j.Output, line)
-	
-	// Keep only last 1000 lines to prevent memory issues
-	if len(j.Output) > 1000 {
-		j.Output = j.Output[len(j.Output)-1000:]
+
+	j.linesAdded++
+	if j.memoryLimit > 0 && j.linesAdded%memoryCheckInterval == 0 {
+		j.lowMemory = memoryNearLimit(j.memoryLimit)
+	}
+
+	// Keep only the last lines to prevent memory issues
+	limit := maxOutputLines
+	if j.lowMemory {
+		limit = lowMemoryMaxOutputLines
+	}
+	if len(j.Output) > limit {
+		j.Output = j.Output[len(j.Output)-limit:]
 	}
 	j.outputMux.Unlock()
 
@@ -360,6 +625,43 @@ func (j *MigrationJob) closeSubscribers() {
 	j.subscribers = nil
 }
 
+// recordDryRunLine adds an unsanitized output line to the dry-run report
+func (j *MigrationJob) recordDryRunLine(line string) {
+	if j.dryRun == nil {
+		return
+	}
+	j.dryRunMux.Lock()
+	j.dryRun.AddLine(line)
+	j.dryRunMux.Unlock()
+}
+
+// GetDryRunReport returns the dry-run report, or nil while the job is still
+// running or when it was not a dry run
+func (j *MigrationJob) GetDryRunReport() *DryRunReport {
+	select {
+	case <-j.done:
+		return j.DryRunReport
+	default:
+		return nil
+	}
+}
+
+// setStatus records the final status of the job
+func (j *MigrationJob) setStatus(status string) {
+	j.statusMux.Lock()
+	defer j.statusMux.Unlock()
+
+	j.Status = status
+}
+
+// GetStatus returns the current status of the job
+func (j *MigrationJob) GetStatus() string {
+	j.statusMux.RLock()
+	defer j.statusMux.RUnlock()
+
+	return j.Status
+}
+
 // GetOutput returns all output lines
 func (j *MigrationJob) GetOutput() []string {
 	j.outputMux.RLock()
@@ -370,68 +672,106 @@ func (j *MigrationJob) GetOutput() []string {
 	return output
 }
 
-// parseStats extracts stats from rclone output
+// Patterns matched against rclone stats lines, e.g.
+// "Transferred:   115.477 MiB / 115.477 MiB, 100%, 9.623 MiB/s, ETA 0s"
+// "Transferred:            1 / 10, 10%"
+// "Checks:                 5 / 10, 50%"
+// " *                   wp-content/image.jpg: 45% /2.3Mi, 1.2Mi/s, 1s"
+var (
+	statsBytesPattern   = regexp.MustCompile(`Transferred:\s*([\d.]+)\s*([KMGTP]?i?B) / ([\d.]+)\s*([KMGTP]?i?B)`)
+	statsPercentPattern = regexp.MustCompile(`(\d+\.?\d*)%`)
+	statsSpeedPattern   = regexp.MustCompile(`(\d+\.?\d*) ?([KMGT]?i?B)/s`)
+	statsETAPattern     = regexp.MustCompile(`ETA ((?:\d+[dhms])+)`)
+	statsChecksPattern  = regexp.MustCompile(`Checks:\s*(\d+) / (\d+)`)
+	statsFilesPattern   = regexp.MustCompile(`Transferred:\s*(\d+) / (\d+)`)
+	statsCurrentPattern = regexp.MustCompile(`^\*\s+(.+?):\s+\d+%`)
+	etaComponentPattern = regexp.MustCompile(`(\d+)([dhms])`)
+)
+
+// parseStats extracts stats from rclone output and broadcasts a "stats"
+// event whenever any field changes
 func (j *MigrationJob) parseStats(line string) {
 	line = strings.TrimSpace(line)
-	updated := false
-	
-	// Example: Transferred: 115.477 MiB / 115.477 MiB, 100%, 9.623 MiB/s, ETA 0s
-	if strings.HasPrefix(line, "Transferred:") {
-		if strings.Contains(line, ",") {
-			parts := strings.Split(line, ",")
-			if len(parts) >= 3 {
-				// Extract Speed
-				speed := strings.TrimSpace(parts[2])
-				if strings.Contains(speed, "/s") {
-					j.Stats.TransferSpeed = speed
-					updated = true
-				}
-				
-				// Extract Total Bytes
-				byteParts := strings.Split(parts[0], "/")
-				if len(byteParts) == 2 {
-					totalStr := strings.TrimSpace(byteParts[1])
-					j.Stats.TotalBytes = parseSizeString(totalStr)
-					updated = true
-				}
-			}
+
+	j.statsMux.Lock()
+	before := j.Stats
+	stats := &j.Stats
+
+	if m := statsBytesPattern.FindStringSubmatch(line); m != nil {
+		stats.TotalBytes = parseSizeString(m[3] + " " + m[4])
+
+		if pm := statsPercentPattern.FindStringSubmatch(line); pm != nil {
+			stats.PercentComplete, _ = strconv.ParseFloat(pm[1], 64)
 		}
-		
-		if !strings.Contains(line, "/s") && strings.Contains(line, "/") {
-			// Likely files: Transferred: 0 / 1, 0%
-			parts := strings.Split(line, ",")
-			if len(parts) >= 1 {
-				fileParts := strings.Split(parts[0], "/")
-				if len(fileParts) == 2 {
-					totalFilesStr := strings.TrimSpace(fileParts[1])
-					var totalFiles int64
-					fmt.Sscanf(totalFilesStr, "%d", &totalFiles)
-					if totalFiles > 0 {
-						j.Stats.TotalFiles = totalFiles
-						updated = true
-					}
-				}
-			}
+		if sm := statsSpeedPattern.FindStringSubmatch(line); sm != nil {
+			stats.TransferSpeed = fmt.Sprintf("%s %s/s", sm[1], sm[2])
+		}
+		if em := statsETAPattern.FindStringSubmatch(line); em != nil {
+			stats.ETASeconds = parseETA(em[1])
+		}
+	} else if m := statsFilesPattern.FindStringSubmatch(line); m != nil {
+		if total, err := strconv.ParseInt(m[2], 10, 64); err == nil && total > 0 {
+			stats.TotalFiles = total
 		}
 	}
 
-	if updated {
-		j.subMux.RLock()
-		defer j.subMux.RUnlock()
-		
-		statsCopy := j.Stats // Copy struct
-		event := StreamEvent{
-			Type:  "stats",
-			Stats: &statsCopy,
+	if m := statsChecksPattern.FindStringSubmatch(line); m != nil {
+		stats.ChecksDone, _ = strconv.ParseInt(m[1], 10, 64)
+		stats.ChecksTotal, _ = strconv.ParseInt(m[2], 10, 64)
+	}
+
+	if m := statsCurrentPattern.FindStringSubmatch(line); m != nil {
+		stats.CurrentFile = m[1]
+	}
+
+	statsCopy := j.Stats // Copy struct
+	j.statsMux.Unlock()
+
+	if statsCopy == before {
+		return
+	}
+
+	j.subMux.RLock()
+	defer j.subMux.RUnlock()
+
+	event := StreamEvent{
+		Type:  "stats",
+		Stats: &statsCopy,
+	}
+
+	for _, ch := range j.subscribers {
+		select {
+		case ch <- event:
+		default:
 		}
-		
-		for _, ch := range j.subscribers {
-			select {
-			case ch <- event:
-			default:
-			}
+	}
+}
+
+// GetStats returns a snapshot of the live stats
+func (j *MigrationJob) GetStats() JobStats {
+	j.statsMux.RLock()
+	defer j.statsMux.RUnlock()
+
+	return j.Stats
+}
+
+// parseETA converts an rclone ETA such as "1h2m3s" or "2d4h" to seconds
+func parseETA(eta string) int64 {
+	var seconds int64
+	for _, m := range etaComponentPattern.FindAllStringSubmatch(eta, -1) {
+		value, _ := strconv.ParseInt(m[1], 10, 64)
+		switch m[2] {
+		case "d":
+			seconds += value * 86400
+		case "h":
+			seconds += value * 3600
+		case "m":
+			seconds += value * 60
+		case "s":
+			seconds += value
 		}
 	}
+	return seconds
 }
 
 func parseSizeString(s string) int64 {
@@ -0,0 +1,205 @@
+package rclone
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A stats block as printed by rclone -v every --stats interval
+var rcloneStatsBlock = []string{
+	"2024/01/15 10:30:10 INFO  : ",
+	"Transferred:   	    1.500 MiB / 10.000 MiB, 15%, 256.000 KiB/s, ETA 1m2s",
+	"Checks:                10 / 20, 50%",
+	"Transferred:            3 / 15, 20%",
+	"Elapsed time:         5.0s",
+	"Transferring:",
+	" *                            wp-content/uploads/big.zip: 45% /8.000Mi, 1.200Mi/s, 3s",
+}
+
+func TestParseStats(t *testing.T) {
+	job := &MigrationJob{}
+	for _, line := range rcloneStatsBlock {
+		job.parseStats(line)
+	}
+
+	want := JobStats{
+		TotalBytes:      10 * 1024 * 1024,
+		TotalFiles:      15,
+		TransferSpeed:   "256.000 KiB/s",
+		PercentComplete: 15,
+		ETASeconds:      62,
+		ChecksDone:      10,
+		ChecksTotal:     20,
+		CurrentFile:     "wp-content/uploads/big.zip",
+	}
+	if got := job.GetStats(); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseStatsKeepsValuesMissingFromLine(t *testing.T) {
+	job := &MigrationJob{}
+	job.parseStats("Transferred:   	    1.500 MiB / 10.000 MiB, 15%, 256.000 KiB/s, ETA 1m2s")
+	// Near the end rclone prints no ETA, and file counts of 0 / 0 before
+	// it has listed anything
+	job.parseStats("Transferred:   	    9.900 MiB / 10.000 MiB, 99%, 1.000 MiB/s, ETA -")
+	job.parseStats("Transferred:            0 / 0, -")
+
+	stats := job.GetStats()
+	if stats.PercentComplete != 99 || stats.TransferSpeed != "1.000 MiB/s" {
+		t.Errorf("stats = %+v, want the latest percent and speed", stats)
+	}
+	if stats.ETASeconds != 62 {
+		t.Errorf("ETASeconds = %d, want the last known ETA", stats.ETASeconds)
+	}
+	if stats.TotalFiles != 0 {
+		t.Errorf("TotalFiles = %d, want 0", stats.TotalFiles)
+	}
+}
+
+func TestParseStatsPublishesChanges(t *testing.T) {
+	job := &MigrationJob{}
+	ch := job.Subscribe()
+
+	job.parseStats("Checks:                10 / 20, 50%")
+	job.parseStats("Checks:                10 / 20, 50%")
+	job.parseStats("2024/01/15 10:30:10 INFO  : index.php: Copied (new)")
+
+	if len(ch) != 1 {
+		t.Fatalf("got %d events, want 1 for the single change", len(ch))
+	}
+	if event := <-ch; event.Type != "stats" || event.Stats.ChecksDone != 10 {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestParseETA(t *testing.T) {
+	tests := []struct {
+		eta  string
+		want int64
+	}{
+		{"45s", 45},
+		{"1m2s", 62},
+		{"2h0m30s", 7230},
+		{"1d2h", 93600},
+		{"-", 0},
+	}
+	for _, tt := range tests {
+		if got := parseETA(tt.eta); got != tt.want {
+			t.Errorf("parseETA(%q) = %d, want %d", tt.eta, got, tt.want)
+		}
+	}
+}
+
+func TestFilterArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts MigrationOptions
+		want []string
+	}{
+		{"no filters", MigrationOptions{}, []string{}},
+		{
+			"excludes",
+			MigrationOptions{Excludes: []string{"cache/**"}, ExcludeExtensions: []string{".log", ".tmp"}},
+			[]string{"--exclude", "cache/**", "--exclude", "*.log", "--exclude", "*.tmp"},
+		},
+		{
+			"includes",
+			MigrationOptions{IncludeExtensions: []string{".jpg", ".png"}},
+			[]string{"--filter", "+ *.jpg", "--filter", "+ *.png", "--filter", "- **"},
+		},
+		{
+			// Excludes come first so they win over an included extension
+			"includes and excludes",
+			MigrationOptions{Excludes: []string{"private/**"}, IncludeExtensions: []string{".jpg"}, ExcludeExtensions: []string{".log"}},
+			[]string{"--exclude", "private/**", "--exclude", "*.log", "--filter", "+ *.jpg", "--filter", "- **"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := filterArgs(tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: filterArgs = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// startMigrationArgs runs a migration with a fake rclone and returns the
+// arguments rclone was started with
+func startMigrationArgs(t *testing.T, opts MigrationOptions) string {
+	t.Helper()
+	logFile := fakeRcloneLog(t, "exit 0")
+	opts.SourceRemote, opts.DestRemote = "src", "dst"
+
+	job, err := NewExecutor("").StartMigration(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.WaitForCompletion(10 * time.Second) {
+		t.Fatal("job did not complete")
+	}
+
+	calls := readCalls(t, logFile)
+	if len(calls) != 1 {
+		t.Fatalf("calls = %q, want one rclone run", calls)
+	}
+	return calls[0]
+}
+
+func TestStartMigrationAgeFlags(t *testing.T) {
+	paris := time.FixedZone("CET", 3600)
+	since := time.Date(2024, 1, 15, 11, 30, 0, 0, paris)
+
+	tests := []struct {
+		name    string
+		opts    MigrationOptions
+		want    []string
+		notWant []string
+	}{
+		{"no age filters", MigrationOptions{}, nil, []string{"--min-age", "--max-age"}},
+		{"exclude modified within", MigrationOptions{ExcludeModifiedWithin: "90m"}, []string{"--min-age=5400s"}, []string{"--max-age"}},
+		{"fractional seconds", MigrationOptions{ExcludeModifiedWithin: "1.5s"}, []string{"--min-age=1s"}, nil},
+		{"invalid window", MigrationOptions{ExcludeModifiedWithin: "soon"}, nil, []string{"--min-age"}},
+		{"incremental since", MigrationOptions{IncrementalSince: &since}, []string{"--max-age 2024-01-15T10:30:00Z"}, []string{"--min-age"}},
+		{
+			"both",
+			MigrationOptions{IncrementalSince: &since, ExcludeModifiedWithin: "24h"},
+			[]string{"--max-age 2024-01-15T10:30:00Z", "--min-age=86400s"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := startMigrationArgs(t, tt.opts)
+			for _, want := range tt.want {
+				if !strings.Contains(args, want) {
+					t.Errorf("args %q do not contain %q", args, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(args, notWant) {
+					t.Errorf("args %q contain %q", args, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestStartMigrationSingleFile(t *testing.T) {
+	args := startMigrationArgs(t, MigrationOptions{
+		SourcePath:     "/var/www/wp-config.php",
+		DestPath:       "/srv/www/wp-config.php",
+		SingleFileMode: true,
+	})
+	if !strings.HasPrefix(args, "copyto src:/var/www/wp-config.php dst:/srv/www/wp-config.php ") {
+		t.Errorf("args = %q, want a copyto between the two files", args)
+	}
+
+	args = startMigrationArgs(t, MigrationOptions{SourcePath: "/var/www", DestPath: "/srv/www"})
+	if !strings.HasPrefix(args, "copy src:/var/www dst:/srv/www ") {
+		t.Errorf("args = %q, want a copy between the two directories", args)
+	}
+}
@@ -0,0 +1,114 @@
+package rclone
+
+import (
+	"bufio"
+	"strings"
+)
+
+// ExcludesToGitignore converts rclone exclude patterns to a .gitignore file.
+// Patterns gitignore cannot express (brace alternatives, character class
+// negation with ^) are kept as comments.
+func ExcludesToGitignore(excludes []string) string {
+	var b strings.Builder
+	b.WriteString("# Exclude rules exported from Website Mover\n")
+
+	present := make(map[string]bool, len(excludes))
+	for _, exclude := range excludes {
+		present[strings.TrimSpace(exclude)] = true
+	}
+
+	for _, exclude := range excludes {
+		exclude = strings.TrimSpace(exclude)
+		if exclude == "" {
+			continue
+		}
+		// A bare gitignore rule already covers the contents of a directory
+		// with that name, so "x/**" next to "x" needs no rule of its own
+		if base, ok := strings.CutSuffix(exclude, "/**"); ok && present[base] {
+			continue
+		}
+		if strings.ContainsAny(exclude, "{}") || strings.Contains(exclude, "[^") {
+			b.WriteString("# unsupported rclone pattern: " + exclude + "\n")
+			continue
+		}
+		b.WriteString(excludeToGitignore(exclude) + "\n")
+	}
+
+	return b.String()
+}
+
+// excludeToGitignore converts a single rclone pattern
+func excludeToGitignore(exclude string) string {
+	// "dir/**" excludes everything below dir, which gitignore writes as "dir/"
+	if dir, ok := strings.CutSuffix(exclude, "/**"); ok && dir != "" && !strings.HasSuffix(dir, "/") {
+		exclude = dir + "/"
+	}
+
+	// rclone only anchors patterns starting with "/", while gitignore also
+	// anchors patterns with a slash in the middle
+	if !strings.HasPrefix(exclude, "/") && !strings.HasPrefix(exclude, "**/") &&
+		strings.Contains(strings.TrimSuffix(exclude, "/"), "/") {
+		exclude = "**/" + exclude
+	}
+
+	if strings.HasPrefix(exclude, "#") || strings.HasPrefix(exclude, "!") {
+		exclude = `\` + exclude
+	}
+	return exclude
+}
+
+// GitignoreToExcludes converts a .gitignore file to rclone exclude patterns.
+// Negated rules ("!pattern") cannot be expressed as excludes and are
+// returned separately so the caller can report them.
+func GitignoreToExcludes(content string) (excludes []string, skipped []string) {
+	seen := make(map[string]bool)
+	add := func(pattern string) {
+		if !seen[pattern] {
+			seen[pattern] = true
+			excludes = append(excludes, pattern)
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			skipped = append(skipped, line)
+			continue
+		}
+		line = strings.TrimPrefix(line, `\`)
+
+		for _, pattern := range gitignoreToExcludes(line) {
+			add(pattern)
+		}
+	}
+
+	return excludes, skipped
+}
+
+// gitignoreToExcludes converts a single gitignore rule. rclone matches "**"
+// itself, so globstars are passed through unchanged.
+func gitignoreToExcludes(rule string) []string {
+	dirOnly := strings.HasSuffix(rule, "/")
+	rule = strings.TrimSuffix(rule, "/")
+
+	// A slash at the start or in the middle anchors the rule to the root;
+	// a leading "**/" matches at any depth, like an unanchored rclone pattern
+	if unanchored, ok := strings.CutPrefix(rule, "**/"); ok && !strings.Contains(unanchored, "/") {
+		rule = unanchored
+	} else if strings.Contains(rule, "/") && !strings.HasPrefix(rule, "**/") {
+		rule = "/" + strings.TrimPrefix(rule, "/")
+	}
+
+	if dirOnly {
+		return []string{rule + "/**"}
+	}
+	if rule == "**" || strings.HasSuffix(rule, "/**") {
+		return []string{rule}
+	}
+	// Without a trailing slash gitignore matches files and directories
+	return []string{rule, rule + "/**"}
+}
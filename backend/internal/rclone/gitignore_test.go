@@ -0,0 +1,102 @@
+package rclone
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExcludesToGitignore(t *testing.T) {
+	gitignore := ExcludesToGitignore([]string{
+		"*.log",
+		" cache/** ",
+		"/wp-content/cache/**",
+		"wp-content/uploads/*.tmp",
+		"**/node_modules/**",
+		"vendor",
+		"vendor/**",
+		"#notes",
+		"!important",
+		"*.{jpg,png}",
+		"[^a]*.txt",
+		"",
+	})
+
+	want := []string{
+		"# Exclude rules exported from Website Mover",
+		"*.log",
+		"cache/",
+		"/wp-content/cache/",
+		"**/wp-content/uploads/*.tmp",
+		"**/node_modules/",
+		"vendor",
+		`\#notes`,
+		`\!important`,
+		"# unsupported rclone pattern: *.{jpg,png}",
+		"# unsupported rclone pattern: [^a]*.txt",
+	}
+	if got := strings.Split(strings.TrimSuffix(gitignore, "\n"), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExcludesToGitignore =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGitignoreToExcludes(t *testing.T) {
+	excludes, skipped := GitignoreToExcludes(`# comment
+
+*.log
+cache/
+/build
+docs/tmp
+**/logs
+**/a/b
+dist/**
+!keep.log
+\#file
+trailing   
+*.log
+`)
+
+	wantExcludes := []string{
+		"*.log", "*.log/**",
+		"cache/**",
+		"/build", "/build/**",
+		"/docs/tmp", "/docs/tmp/**",
+		"logs", "logs/**",
+		"**/a/b", "**/a/b/**",
+		"/dist/**",
+		"#file", "#file/**",
+		"trailing", "trailing/**",
+	}
+	if !reflect.DeepEqual(excludes, wantExcludes) {
+		t.Errorf("excludes = %q, want %q", excludes, wantExcludes)
+	}
+	if want := []string{"!keep.log"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped = %q, want %q", skipped, want)
+	}
+}
+
+// Exporting excludes and importing them back must keep their anchoring:
+// rclone patterns are unanchored unless they start with "/"
+func TestGitignoreRoundTrip(t *testing.T) {
+	tests := []struct {
+		exclude string
+		want    []string
+	}{
+		{"*.log", []string{"*.log", "*.log/**"}},
+		{"cache/**", []string{"cache/**"}},
+		{"/wp-content/cache/**", []string{"/wp-content/cache/**"}},
+		{"/wp-config.php", []string{"/wp-config.php", "/wp-config.php/**"}},
+		{"wp-content/uploads/*.tmp", []string{"**/wp-content/uploads/*.tmp", "**/wp-content/uploads/*.tmp/**"}},
+		{"**/node_modules/**", []string{"node_modules/**"}},
+	}
+
+	for _, tt := range tests {
+		excludes, skipped := GitignoreToExcludes(ExcludesToGitignore([]string{tt.exclude}))
+		if len(skipped) > 0 {
+			t.Errorf("%q: skipped %q", tt.exclude, skipped)
+		}
+		if !reflect.DeepEqual(excludes, tt.want) {
+			t.Errorf("%q: round trip = %q, want %q", tt.exclude, excludes, tt.want)
+		}
+	}
+}
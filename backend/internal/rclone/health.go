@@ -0,0 +1,73 @@
+package rclone
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RemoteHealth is the result of testing a single remote
+type RemoteHealth struct {
+	Name       string    `json:"name"`
+	Success    bool      `json:"success"`
+	Message    string    `json:"message"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	TestedAt   time.Time `json:"tested_at"`
+}
+
+// HealthReport summarizes a test of several remotes
+type HealthReport struct {
+	Results  []RemoteHealth `json:"results"`
+	Total    int            `json:"total"`
+	OK       int            `json:"ok"`
+	Failed   int            `json:"failed"`
+	TestedAt time.Time      `json:"tested_at"`
+}
+
+// TestRemotes tests the root of every named remote, running at most
+// concurrency tests at once. Results keep the order of names.
+func (e *Executor) TestRemotes(ctx context.Context, names []string, concurrency int) *HealthReport {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]RemoteHealth, len(names))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			start := time.Now()
+			result := e.TestRemote(ctx, name, "")
+			results[i] = RemoteHealth{
+				Name:       name,
+				Success:    result.Success,
+				Message:    result.Message,
+				Error:      result.Error,
+				DurationMs: time.Since(start).Milliseconds(),
+				TestedAt:   time.Now(),
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	report := &HealthReport{
+		Results:  results,
+		Total:    len(results),
+		TestedAt: time.Now(),
+	}
+	for _, result := range results {
+		if result.Success {
+			report.OK++
+		} else {
+			report.Failed++
+		}
+	}
+	return report
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -24,6 +26,77 @@ type MigrationHistory struct {
 	TotalBytes    int64  `json:"total_bytes"`
 	TotalFiles    int64  `json:"total_files"`
 	TransferSpeed string `json:"transfer_speed"`
+
+	// Cutoff used by an incremental migration (files older than this were skipped)
+	IncrementalSince *time.Time `json:"incremental_since,omitempty"`
+
+	// ID of the history entry this migration replayed, if any
+	ReplayOfID string `json:"replay_of_id,omitempty"`
+
+	// ID of the failed entry this migration retried, if any, the original
+	// migration of the chain of retries, and the number of retries of that
+	// original so far
+	RetryOfID   string `json:"retry_of_id,omitempty"`
+	RetryRootID string `json:"retry_root_id,omitempty"`
+	RetryCount  int    `json:"retry_count,omitempty"`
+
+	// ID of the batch migration this entry was part of, if any
+	BatchID string `json:"batch_id,omitempty"`
+
+	// Latest post-migration verification, if one was run
+	VerifyJobID  string       `json:"verify_job_id,omitempty"`
+	Verification *CheckResult `json:"verification,omitempty"`
+
+	// Partial files removed at the destination after a failure, if cleaned up
+	Cleanup *CleanupResult `json:"cleanup,omitempty"`
+
+	// Dry-run report (only set for dry-run migrations)
+	DryRunReport *DryRunReport `json:"dry_run_report,omitempty"`
+}
+
+// HistoryCSVHeader lists the columns written by MigrationHistory.CSVRecord
+var HistoryCSVHeader = []string{
+	"timestamp", "source_remote", "source_path", "dest_remote", "dest_path", "status",
+	"files_transferred", "bytes_transferred", "duration_seconds", "average_speed_mbps", "errors_count",
+}
+
+// DurationSeconds returns the wall-clock duration of the migration
+func (h MigrationHistory) DurationSeconds() float64 {
+	return h.EndTime.Sub(h.StartTime).Seconds()
+}
+
+// ErrorCount returns the number of ERROR lines in the stored output
+func (h MigrationHistory) ErrorCount() int {
+	count := 0
+	for _, line := range h.Output {
+		if strings.Contains(line, "ERROR") {
+			count++
+		}
+	}
+	return count
+}
+
+// CSVRecord returns the entry as a CSV row matching HistoryCSVHeader
+func (h MigrationHistory) CSVRecord() []string {
+	duration := h.DurationSeconds()
+	var speedMbps float64
+	if duration > 0 {
+		speedMbps = float64(h.TotalBytes) * 8 / 1e6 / duration
+	}
+
+	return []string{
+		h.StartTime.Format(time.RFC3339),
+		h.Options.SourceRemote,
+		h.Options.SourcePath,
+		h.Options.DestRemote,
+		h.Options.DestPath,
+		h.Status,
+		strconv.FormatInt(h.TotalFiles, 10),
+		strconv.FormatInt(h.TotalBytes, 10),
+		strconv.FormatFloat(duration, 'f', 0, 64),
+		strconv.FormatFloat(speedMbps, 'f', 2, 64),
+		strconv.Itoa(h.ErrorCount()),
+	}
 }
 
 // HistoryStore manages migration history
@@ -65,20 +138,29 @@ func (hs *HistoryStore) Add(job *MigrationJob, endTime time.Time) error {
 	hs.mux.Lock()
 	defer hs.mux.Unlock()
 
+	stats := job.GetStats()
 	history := MigrationHistory{
 		ID:        job.ID,
-		Options:   job.Options,
+		Options:   job.Options.WithoutSecrets(),
 		Command:   job.Command,
 		StartTime: job.StartTime,
 		EndTime:   endTime,
 		Duration:  endTime.Sub(job.StartTime).Round(time.Second).String(),
-		Status:    job.Status,
+		Status:    job.GetStatus(),
 		Output:    job.GetOutput(),
 		
 		// Stats
-		TotalBytes:    job.Stats.TotalBytes,
-		TotalFiles:    job.Stats.TotalFiles,
-		TransferSpeed: job.Stats.TransferSpeed,
+		TotalBytes:    stats.TotalBytes,
+		TotalFiles:    stats.TotalFiles,
+		TransferSpeed: stats.TransferSpeed,
+
+		IncrementalSince: job.Options.IncrementalSince,
+		ReplayOfID:       job.ReplayOfID,
+		RetryOfID:        job.RetryOfID,
+		RetryRootID:      job.RetryRootID,
+		RetryCount:       job.RetryCount,
+		BatchID:          job.BatchID,
+		DryRunReport:     job.GetDryRunReport(),
 	}
 
 	// Read existing history
@@ -117,7 +199,9 @@ func (hs *HistoryStore) List() ([]MigrationHistory, error) {
 	return histories, nil
 }
 
-// Get returns a specific migration by ID
+// Get returns a specific migration by ID. IDs are compared as opaque strings,
+// so entries recorded with the older timestamp IDs ("mig-1700000000") are
+// still found next to the UUID-based ones.
 func (hs *HistoryStore) Get(id string) (*MigrationHistory, error) {
 	hs.mux.RLock()
 	defer hs.mux.RUnlock()
@@ -136,6 +220,59 @@ func (hs *HistoryStore) Get(id string) (*MigrationHistory, error) {
 	return nil, os.ErrNotExist
 }
 
+// CountRetries returns how many entries retried the original migration rootID,
+// directly or through earlier retries
+func (hs *HistoryStore) CountRetries(rootID string) (int, error) {
+	hs.mux.RLock()
+	defer hs.mux.RUnlock()
+
+	histories, err := hs.loadHistory()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, h := range histories {
+		if h.RetryRootID == rootID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// LastSuccessful returns the most recent completed, non dry-run migration
+// between the given source and destination
+func (hs *HistoryStore) LastSuccessful(sourceRemote, sourcePath, destRemote, destPath string) (*MigrationHistory, error) {
+	hs.mux.RLock()
+	defer hs.mux.RUnlock()
+
+	histories, err := hs.loadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	var last *MigrationHistory
+	for i := range histories {
+		h := &histories[i]
+		if h.Status != "completed" || h.Options.DryRun {
+			continue
+		}
+		if h.Options.SourceRemote != sourceRemote || h.Options.SourcePath != sourcePath ||
+			h.Options.DestRemote != destRemote || h.Options.DestPath != destPath {
+			continue
+		}
+		if last == nil || h.EndTime.After(last.EndTime) {
+			last = h
+		}
+	}
+
+	if last == nil {
+		return nil, os.ErrNotExist
+	}
+
+	return last, nil
+}
+
 func (hs *HistoryStore) loadHistory() ([]MigrationHistory, error) {
 	data, err := os.ReadFile(hs.historyFile)
 	if err != nil {
@@ -159,6 +296,72 @@ func (hs *HistoryStore) saveHistory(histories []MigrationHistory) error {
 	return os.WriteFile(hs.historyFile, data, 0644)
 }
 
+// SetVerification records the verification job of a migration and, once
+// it has finished, its result
+func (hs *HistoryStore) SetVerification(id, verifyJobID string, result *CheckResult) error {
+	hs.mux.Lock()
+	defer hs.mux.Unlock()
+
+	histories, err := hs.loadHistory()
+	if err != nil {
+		return err
+	}
+
+	for i := range histories {
+		if histories[i].ID == id {
+			histories[i].VerifyJobID = verifyJobID
+			histories[i].Verification = result
+			return hs.saveHistory(histories)
+		}
+	}
+
+	return os.ErrNotExist
+}
+
+// SetCleanup records the cleanup of a failed migration's partial files
+func (hs *HistoryStore) SetCleanup(id string, result *CleanupResult) error {
+	hs.mux.Lock()
+	defer hs.mux.Unlock()
+
+	histories, err := hs.loadHistory()
+	if err != nil {
+		return err
+	}
+
+	for i := range histories {
+		if histories[i].ID == id {
+			histories[i].Cleanup = result
+			return hs.saveHistory(histories)
+		}
+	}
+
+	return os.ErrNotExist
+}
+
+// Delete removes a specific migration from history
+func (hs *HistoryStore) Delete(id string) error {
+	hs.mux.Lock()
+	defer hs.mux.Unlock()
+
+	histories, err := hs.loadHistory()
+	if err != nil {
+		return err
+	}
+
+	filtered := make([]MigrationHistory, 0, len(histories))
+	for _, h := range histories {
+		if h.ID != id {
+			filtered = append(filtered, h)
+		}
+	}
+
+	if len(filtered) == len(histories) {
+		return os.ErrNotExist
+	}
+
+	return hs.saveHistory(filtered)
+}
+
 // Clear clears all migration history
 func (hs *HistoryStore) Clear() error {
 	hs.mux.Lock()
@@ -0,0 +1,51 @@
+package rclone
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCSVRecord(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	h := MigrationHistory{
+		Options: MigrationOptions{
+			SourceRemote: "old",
+			SourcePath:   "/var/www",
+			DestRemote:   "new",
+			DestPath:     "/srv/www",
+		},
+		StartTime:  start,
+		EndTime:    start.Add(100 * time.Second),
+		Status:     "failed",
+		TotalFiles: 42,
+		TotalBytes: 250_000_000,
+		Output: []string{
+			"INFO  : index.php: Copied (new)",
+			"ERROR : wp-config.php: Failed to copy: permission denied",
+			"ERROR : Attempt 1/3 failed with 1 errors",
+		},
+	}
+
+	want := []string{
+		"2024-03-01T10:00:00Z", "old", "/var/www", "new", "/srv/www", "failed",
+		"42", "250000000", "100", "20.00", "2",
+	}
+	got := h.CSVRecord()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CSVRecord() = %q, want %q", got, want)
+	}
+	if len(got) != len(HistoryCSVHeader) {
+		t.Errorf("%d columns, header has %d", len(got), len(HistoryCSVHeader))
+	}
+}
+
+func TestCSVRecordZeroDuration(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	h := MigrationHistory{StartTime: start, EndTime: start, TotalBytes: 1000}
+
+	record := h.CSVRecord()
+	if duration, speed := record[8], record[9]; duration != "0" || speed != "0.00" {
+		t.Errorf("duration = %s, speed = %s, want 0 and 0.00", duration, speed)
+	}
+}
@@ -0,0 +1,47 @@
+package rclone
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gonzague/website-mover/backend/internal/sshutil"
+)
+
+// GeneratedKeyPath returns where the inline key of a remote is stored
+func (cm *ConfigManager) GeneratedKeyPath(name string) string {
+	return filepath.Join(cm.keysDir, name+".pem")
+}
+
+// storeKeyPEM validates an inline private key and writes it to a temp file
+// next to the remote's key file, readable only by the current user. The
+// caller renames it to GeneratedKeyPath once the config is saved, so a failed
+// save neither leaves an orphan key nor overwrites the previous one.
+func (cm *ConfigManager) storeKeyPEM(name, keyPEM string) (string, error) {
+	if _, err := sshutil.ValidateSSHKey(keyPEM); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(cm.keysDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create keys dir: %w", err)
+	}
+
+	// CreateTemp opens the file with mode 0600
+	tmpFile, err := os.CreateTemp(cm.keysDir, name+".pem.tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create key file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.WriteString(keyPEM); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	return tmpPath, nil
+}
@@ -0,0 +1,107 @@
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// SizeInfo is the number and total size of the objects rclone would transfer
+type SizeInfo struct {
+	Count int64 `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// LimitCheck compares the size of a migration with its MaxFiles and
+// MaxTotalBytes limits
+type LimitCheck struct {
+	ActualFiles   int64 `json:"actual_files"`
+	MaxFiles      int   `json:"max_files,omitempty"`
+	ActualBytes   int64 `json:"actual_bytes"`
+	MaxBytes      int64 `json:"max_bytes,omitempty"`
+	FilesExceeded bool  `json:"files_exceeded"`
+	BytesExceeded bool  `json:"bytes_exceeded"`
+}
+
+// Exceeded reports whether any limit is exceeded
+func (c *LimitCheck) Exceeded() bool {
+	return c.FilesExceeded || c.BytesExceeded
+}
+
+// LimitExceededError is returned when a migration exceeds a limit that is
+// enforced rather than only warned about
+type LimitExceededError struct {
+	Check *LimitCheck
+}
+
+func (e *LimitExceededError) Error() string {
+	var exceeded []string
+	if e.Check.FilesExceeded {
+		exceeded = append(exceeded, fmt.Sprintf("%d files (max %d)", e.Check.ActualFiles, e.Check.MaxFiles))
+	}
+	if e.Check.BytesExceeded {
+		exceeded = append(exceeded, fmt.Sprintf("%d bytes (max %d)", e.Check.ActualBytes, e.Check.MaxBytes))
+	}
+	return "migration would exceed its limits: " + strings.Join(exceeded, ", ")
+}
+
+// HasSizeLimits reports whether the options limit the number of files or bytes
+func (o MigrationOptions) HasSizeLimits() bool {
+	return o.MaxFiles > 0 || o.MaxTotalBytes > 0
+}
+
+// CheckLimits compares size with the limits of opts. It returns a
+// *LimitExceededError when an enforced limit is exceeded.
+func CheckLimits(opts MigrationOptions, size *SizeInfo) (*LimitCheck, error) {
+	check := &LimitCheck{
+		ActualFiles: size.Count,
+		MaxFiles:    opts.MaxFiles,
+		ActualBytes: size.Bytes,
+		MaxBytes:    opts.MaxTotalBytes,
+	}
+	check.FilesExceeded = opts.MaxFiles > 0 && size.Count > int64(opts.MaxFiles)
+	check.BytesExceeded = opts.MaxTotalBytes > 0 && size.Bytes > opts.MaxTotalBytes
+
+	if (check.FilesExceeded && opts.MaxFilesCheck) || (check.BytesExceeded && opts.MaxBytesCheck) {
+		return check, &LimitExceededError{Check: check}
+	}
+	return check, nil
+}
+
+// MigrationSize runs rclone size on the source of a migration, with the same
+// filters the migration will use
+func (e *Executor) MigrationSize(ctx context.Context, opts MigrationOptions) (*SizeInfo, error) {
+	args := []string{fmt.Sprintf("%s:%s", opts.SourceRemote, opts.SourcePath)}
+	args = append(args, filterArgs(opts)...)
+	if opts.IncrementalSince != nil {
+		args = append(args, "--max-age", opts.IncrementalSince.UTC().Format(time.RFC3339))
+	}
+	if within, err := time.ParseDuration(opts.ExcludeModifiedWithin); err == nil && within > 0 {
+		args = append(args, fmt.Sprintf("--min-age=%ds", int64(within.Seconds())))
+	}
+
+	return e.size(ctx, args)
+}
+
+// size runs rclone size --json with the given remote path and flags
+func (e *Executor) size(ctx context.Context, args []string) (*SizeInfo, error) {
+	cmd := exec.CommandContext(ctx, "rclone", append([]string{"size", "--json"}, args...)...)
+	if e.configPath != "" {
+		cmd.Args = append(cmd.Args, "--config", e.configPath)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("rclone size failed: %w", err)
+	}
+
+	var size SizeInfo
+	if err := json.Unmarshal(output, &size); err != nil {
+		return nil, fmt.Errorf("failed to parse rclone size output: %w", err)
+	}
+
+	return &size, nil
+}
@@ -0,0 +1,104 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckLimits(t *testing.T) {
+	size := &SizeInfo{Count: 100, Bytes: 5000}
+
+	tests := []struct {
+		name          string
+		opts          MigrationOptions
+		filesExceeded bool
+		bytesExceeded bool
+		wantErr       bool
+	}{
+		{"no limits", MigrationOptions{}, false, false, false},
+		{"within limits", MigrationOptions{MaxFiles: 100, MaxTotalBytes: 5000, MaxFilesCheck: true, MaxBytesCheck: true}, false, false, false},
+		{"files exceeded, warn only", MigrationOptions{MaxFiles: 99}, true, false, false},
+		{"bytes exceeded, warn only", MigrationOptions{MaxTotalBytes: 4999}, false, true, false},
+		{"files exceeded, enforced", MigrationOptions{MaxFiles: 99, MaxFilesCheck: true}, true, false, true},
+		{"bytes exceeded, enforced", MigrationOptions{MaxTotalBytes: 4999, MaxBytesCheck: true}, false, true, true},
+		{"bytes exceeded, only files enforced", MigrationOptions{MaxFiles: 100, MaxTotalBytes: 4999, MaxFilesCheck: true}, false, true, false},
+		{"enforced without a limit", MigrationOptions{MaxFilesCheck: true, MaxBytesCheck: true}, false, false, false},
+	}
+
+	for _, tt := range tests {
+		check, err := CheckLimits(tt.opts, size)
+		if check.FilesExceeded != tt.filesExceeded || check.BytesExceeded != tt.bytesExceeded {
+			t.Errorf("%s: check = %+v", tt.name, check)
+		}
+		if check.ActualFiles != 100 || check.ActualBytes != 5000 {
+			t.Errorf("%s: actual size not recorded: %+v", tt.name, check)
+		}
+
+		var limitErr *LimitExceededError
+		if got := errors.As(err, &limitErr); got != tt.wantErr {
+			t.Errorf("%s: err = %v, want LimitExceededError %v", tt.name, err, tt.wantErr)
+		}
+		if limitErr != nil && limitErr.Check != check {
+			t.Errorf("%s: error does not carry the check", tt.name)
+		}
+	}
+}
+
+func TestLimitExceededErrorMessage(t *testing.T) {
+	err := &LimitExceededError{Check: &LimitCheck{ActualFiles: 12, MaxFiles: 10, FilesExceeded: true, ActualBytes: 5}}
+	want := "migration would exceed its limits: 12 files (max 10)"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestMigrationSize(t *testing.T) {
+	logFile := fakeRcloneLog(t, `echo '{"count":1234,"bytes":56789,"sizeless":0}'`)
+
+	since := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	size, err := NewExecutor("").MigrationSize(context.Background(), MigrationOptions{
+		SourceRemote:          "src",
+		SourcePath:            "/var/www",
+		ExcludeExtensions:     []string{".log"},
+		IncrementalSince:      &since,
+		ExcludeModifiedWithin: "1h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size.Count != 1234 || size.Bytes != 56789 {
+		t.Errorf("size = %+v, want 1234 files and 56789 bytes", size)
+	}
+
+	calls := readCalls(t, logFile)
+	if len(calls) != 1 {
+		t.Fatalf("calls = %q", calls)
+	}
+	for _, want := range []string{"size --json src:/var/www", "--exclude *.log", "--max-age 2024-01-15T10:30:00Z", "--min-age=3600s"} {
+		if !strings.Contains(calls[0], want) {
+			t.Errorf("call %q does not contain %q", calls[0], want)
+		}
+	}
+}
+
+func TestMigrationSizeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"rclone fails", "echo 'directory not found' >&2; exit 3"},
+		{"invalid output", "echo 'not json'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFakeRclone(t, tt.script)
+			if _, err := NewExecutor("").MigrationSize(context.Background(), MigrationOptions{SourceRemote: "src"}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
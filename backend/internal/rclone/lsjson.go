@@ -0,0 +1,108 @@
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort fields accepted by SortFileItems
+const (
+	SortByName    = "name"
+	SortBySize    = "size"
+	SortByModTime = "mod_time"
+)
+
+// lsjsonItem is one entry of `rclone lsjson` output
+type lsjsonItem struct {
+	Path     string    `json:"Path"`
+	Name     string    `json:"Name"`
+	Size     int64     `json:"Size"`
+	MimeType string    `json:"MimeType"`
+	ModTime  time.Time `json:"ModTime"`
+	IsDir    bool      `json:"IsDir"`
+	ID       string    `json:"ID"`
+	Tier     string    `json:"Tier"`
+}
+
+// ListPathRich lists a remote path with modification times and MIME types
+// using rclone lsjson. If lsjson is not available (very old rclone), it falls
+// back to ListPath, which only returns names and sizes.
+func (e *Executor) ListPathRich(ctx context.Context, remoteName, path string) ([]FileItem, error) {
+	remotePath := fmt.Sprintf("%s:%s", remoteName, path)
+
+	cmd := exec.CommandContext(ctx, "rclone", "lsjson", remotePath, "--max-depth", "1")
+	if e.configPath != "" {
+		cmd.Args = append(cmd.Args, "--config", e.configPath)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && strings.Contains(string(exitErr.Stderr), "unknown command") {
+			log.Printf("WARNING: rclone lsjson is not available, falling back to lsf without metadata")
+			return e.ListPath(ctx, remoteName, path)
+		}
+		return nil, fmt.Errorf("rclone lsjson failed: %w", err)
+	}
+
+	var entries []lsjsonItem
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse rclone lsjson output: %w", err)
+	}
+
+	items := make([]FileItem, 0, len(entries))
+	for _, entry := range entries {
+		size := entry.Size
+		if entry.IsDir {
+			// rclone reports -1 for directories
+			size = 0
+		}
+		items = append(items, FileItem{
+			Name:     entry.Name,
+			IsDir:    entry.IsDir,
+			Size:     size,
+			ModTime:  entry.ModTime,
+			MimeType: entry.MimeType,
+			ID:       entry.ID,
+		})
+	}
+
+	return items, nil
+}
+
+// SortFileItems sorts items in place by name, size or mod_time. Directories
+// are always listed before files; ties are broken by name.
+func SortFileItems(items []FileItem, sortBy string, descending bool) error {
+	var less func(a, b FileItem) bool
+	switch sortBy {
+	case "", SortByName:
+		less = func(a, b FileItem) bool { return a.Name < b.Name }
+	case SortBySize:
+		less = func(a, b FileItem) bool { return a.Size < b.Size }
+	case SortByModTime:
+		less = func(a, b FileItem) bool { return a.ModTime.Before(b.ModTime) }
+	default:
+		return fmt.Errorf("invalid sort field %q: must be %s, %s or %s", sortBy, SortByName, SortBySize, SortByModTime)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		if less(a, b) {
+			return !descending
+		}
+		if less(b, a) {
+			return descending
+		}
+		return a.Name < b.Name
+	})
+
+	return nil
+}
@@ -0,0 +1,10 @@
+package rclone
+
+import "runtime"
+
+// memoryNearLimit reports whether the allocated heap is above 90% of limit bytes
+func memoryNearLimit(limit uint64) bool {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Alloc >= limit/10*9
+}
@@ -0,0 +1,44 @@
+package rclone
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestMemoryNearLimit(t *testing.T) {
+	if !memoryNearLimit(1) {
+		t.Error("memoryNearLimit(1) = false, want true: any heap is above a 1 byte limit")
+	}
+	if memoryNearLimit(math.MaxUint64) {
+		t.Error("memoryNearLimit(MaxUint64) = true, want false")
+	}
+}
+
+func TestAddOutputShrinksBufferNearMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		memoryLimit uint64
+		wantLines   int
+	}{
+		{"no limit", 0, maxOutputLines},
+		{"far from limit", math.MaxUint64, maxOutputLines},
+		{"near limit", 1, lowMemoryMaxOutputLines},
+	}
+
+	for _, tt := range tests {
+		job := &MigrationJob{memoryLimit: tt.memoryLimit}
+		for i := 0; i < memoryCheckInterval+500; i++ {
+			job.addOutput(fmt.Sprintf("line %d", i))
+		}
+
+		output := job.GetOutput()
+		if len(output) != tt.wantLines {
+			t.Errorf("%s: kept %d lines, want %d", tt.name, len(output), tt.wantLines)
+			continue
+		}
+		if last := output[len(output)-1]; last != fmt.Sprintf("line %d", memoryCheckInterval+499) {
+			t.Errorf("%s: last line = %q, want the most recent one", tt.name, last)
+		}
+	}
+}
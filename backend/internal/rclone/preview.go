@@ -0,0 +1,188 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os/exec"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// binaryPreviewBytes is how much of a binary file is shown as a hex dump
+const binaryPreviewBytes = 256
+
+var (
+	// ErrNotAFile is returned when previewing a directory or a missing path
+	ErrNotAFile = errors.New("not a file")
+
+	// ErrPreviewNotAllowed is returned for files whose MIME type may not be previewed
+	ErrPreviewNotAllowed = errors.New("preview not allowed for this file type")
+)
+
+// textFileTypes are MIME types of config files that have no registered type
+// or that rclone reports as application/octet-stream
+var textFileTypes = map[string]string{
+	".php":      "text/x-php",
+	".env":      "text/plain",
+	".htaccess": "text/plain",
+	".htpasswd": "text/plain",
+	".ini":      "text/plain",
+	".conf":     "text/plain",
+	".cnf":      "text/plain",
+	".yml":      "text/yaml",
+	".yaml":     "text/yaml",
+	".toml":     "text/plain",
+	".log":      "text/plain",
+}
+
+// FilePreview is the beginning of a remote file, shown to review config files
+// before migrating them
+type FilePreview struct {
+	Path      string `json:"path"`
+	Content   string `json:"content,omitempty"`
+	HexDump   string `json:"hex_dump,omitempty"` // first bytes of binary files
+	IsBinary  bool   `json:"is_binary"`
+	MimeType  string `json:"mime_type"`
+	Size      int64  `json:"size"`
+	Truncated bool   `json:"truncated"`
+}
+
+// PreviewFile reads up to maxBytes from the start of a file on a remote.
+// Files whose MIME type doesn't match allowedTypes (e.g. "text/*") are refused
+// with ErrPreviewNotAllowed; an empty list allows every type.
+func (e *Executor) PreviewFile(ctx context.Context, remoteName, filePath string, maxBytes int64, allowedTypes []string) (*FilePreview, error) {
+	entry, err := e.statFile(ctx, remoteName, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := e.readFileHead(ctx, remoteName, filePath, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &FilePreview{
+		Path:      filePath,
+		MimeType:  detectMimeType(filePath, entry.MimeType, content),
+		Size:      entry.Size,
+		Truncated: entry.Size > int64(len(content)),
+	}
+
+	if !MimeTypeAllowed(preview.MimeType, allowedTypes) {
+		return nil, fmt.Errorf("%w: %s", ErrPreviewNotAllowed, preview.MimeType)
+	}
+
+	if isBinary(content, preview.Truncated) {
+		preview.IsBinary = true
+		if len(content) > binaryPreviewBytes {
+			content = content[:binaryPreviewBytes]
+		}
+		preview.HexDump = hex.Dump(content)
+	} else {
+		preview.Content = string(content)
+	}
+
+	return preview, nil
+}
+
+// statFile returns the lsjson entry of a single file
+func (e *Executor) statFile(ctx context.Context, remoteName, filePath string) (*lsjsonItem, error) {
+	cmd := exec.CommandContext(ctx, "rclone", "lsjson", fmt.Sprintf("%s:%s", remoteName, filePath))
+	if e.configPath != "" {
+		cmd.Args = append(cmd.Args, "--config", e.configPath)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("rclone lsjson failed: %w", err)
+	}
+
+	var entries []lsjsonItem
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse rclone lsjson output: %w", err)
+	}
+
+	// Listing a directory returns its contents, listing a file only the file
+	if len(entries) != 1 || entries[0].IsDir || entries[0].Name != path.Base(filePath) {
+		return nil, fmt.Errorf("%w: %s", ErrNotAFile, filePath)
+	}
+
+	return &entries[0], nil
+}
+
+// readFileHead returns up to limit bytes from the start of a remote file
+func (e *Executor) readFileHead(ctx context.Context, remoteName, filePath string, limit int64) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "rclone", "cat", "--count", fmt.Sprint(limit), fmt.Sprintf("%s:%s", remoteName, filePath))
+	if e.configPath != "" {
+		cmd.Args = append(cmd.Args, "--config", e.configPath)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("rclone cat failed: %w", err)
+	}
+
+	return output, nil
+}
+
+// detectMimeType picks the MIME type of a file from well-known config file
+// extensions, the type reported by rclone, or the content itself
+func detectMimeType(filePath, reported string, content []byte) string {
+	if mimeType, ok := textFileTypes[strings.ToLower(path.Ext(filePath))]; ok {
+		return mimeType
+	}
+
+	if reported == "" || reported == "application/octet-stream" {
+		reported = http.DetectContentType(content)
+	}
+
+	mimeType, _, err := mime.ParseMediaType(reported)
+	if err != nil {
+		return reported
+	}
+	return mimeType
+}
+
+// MimeTypeAllowed reports whether mimeType matches one of patterns, which are
+// either exact types or "type/*" wildcards. An empty list allows everything.
+func MimeTypeAllowed(mimeType string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mimeType, prefix+"/") {
+				return true
+			}
+		} else if mimeType == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinary reports whether content contains null bytes or invalid UTF-8. A
+// truncated read may end in the middle of a multi-byte character.
+func isBinary(content []byte, truncated bool) bool {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return true
+	}
+	if utf8.Valid(content) {
+		return false
+	}
+	if truncated {
+		for i := 1; i < utf8.UTFMax && i < len(content); i++ {
+			if utf8.Valid(content[:len(content)-i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package rclone
+
+import "regexp"
+
+var (
+	// commandSecretPattern matches password flags such as --password=VALUE or --ftps-pass='VALUE'
+	commandSecretPattern = regexp.MustCompile(`(?i)(--[a-z0-9-]*pass(?:word)?=)('[^']*'|\S+)`)
+
+	// outputSecretPattern matches "password: VALUE" style assignments in log lines.
+	// The keyword must start the line or follow whitespace, a quote or a JSON
+	// delimiter, so names such as "site_secret: " or "ssh-key: " are left intact.
+	outputSecretPattern = regexp.MustCompile(`(?i)((?:^|[\s"'{,;])(?:password|pass|secret|key)\s*[:=]\s*)\S+`)
+
+	// skippedNoticePattern matches rclone dry-run notices such as
+	// "NOTICE: secret key: Skipped copy", whose text before ": Skipped" is a
+	// file path, not a secret
+	skippedNoticePattern = regexp.MustCompile(`NOTICE: .+: Skipped `)
+)
+
+// SanitizeCommand masks password flag values in a display command
+func SanitizeCommand(cmd string) string {
+	return commandSecretPattern.ReplaceAllString(cmd, "${1}***")
+}
+
+// SanitizeOutputLine masks password, secret and key values in an output line
+func SanitizeOutputLine(line string) string {
+	if skippedNoticePattern.MatchString(line) {
+		return line
+	}
+	return outputSecretPattern.ReplaceAllString(line, "${1}***")
+}
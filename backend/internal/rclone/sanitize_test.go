@@ -0,0 +1,73 @@
+package rclone
+
+import "testing"
+
+func TestSanitizeCommand(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{
+			"rclone copy src:/ dst:/ --password=hunter2 -v",
+			"rclone copy src:/ dst:/ --password=*** -v",
+		},
+		{
+			"rclone config create ftp1 ftp --ftp-pass='my secret' --ftp-user=bob",
+			"rclone config create ftp1 ftp --ftp-pass=*** --ftp-user=bob",
+		},
+		{
+			"rclone copy src:/ dst:/ --transfers=4",
+			"rclone copy src:/ dst:/ --transfers=4",
+		},
+	}
+	for _, tt := range tests {
+		if got := SanitizeCommand(tt.cmd); got != tt.want {
+			t.Errorf("SanitizeCommand(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeOutputLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"password: hunter2", "password: ***"},
+		{"2024/01/15 10:30:00 DEBUG : login with pass=hunter2 ok", "2024/01/15 10:30:00 DEBUG : login with pass=*** ok"},
+		{"config: key=abc123", "config: key=***"},
+
+		// File names in rclone notices are not secrets
+		{
+			"2024/01/15 10:30:00 NOTICE: config/site_secret: Skipped copy as --dry-run is set (size 1Ki)",
+			"2024/01/15 10:30:00 NOTICE: config/site_secret: Skipped copy as --dry-run is set (size 1Ki)",
+		},
+		{
+			"2024/01/15 10:30:00 NOTICE: ssh-key: Skipped copy as --dry-run is set (size 1Ki)",
+			"2024/01/15 10:30:00 NOTICE: ssh-key: Skipped copy as --dry-run is set (size 1Ki)",
+		},
+		{
+			"2024/01/15 10:30:00 NOTICE: backup key: Skipped delete as --dry-run is set",
+			"2024/01/15 10:30:00 NOTICE: backup key: Skipped delete as --dry-run is set",
+		},
+		{
+			"2024/01/15 10:30:00 INFO  : certs/site.key: Copied (new)",
+			"2024/01/15 10:30:00 INFO  : certs/site.key: Copied (new)",
+		},
+		{"api_key=abc123", "api_key=abc123"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeOutputLine(tt.line); got != tt.want {
+			t.Errorf("SanitizeOutputLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+// Masking output must not drop files from a dry-run report built from it
+func TestSanitizedNoticesKeepDryRunReport(t *testing.T) {
+	lines := []string{
+		SanitizeOutputLine("NOTICE: config/site_secret: Skipped copy as --dry-run is set (size 1)"),
+		SanitizeOutputLine("NOTICE: ssh-key: Skipped copy as --dry-run is set (size 1)"),
+	}
+	report := ParseDryRunOutput(lines)
+	assertNames(t, "transfer", report.WouldTransfer, "config/site_secret", "ssh-key")
+}
@@ -0,0 +1,23 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// UploadFile copies a local file to a file path on a remote with rclone copyto
+func (e *Executor) UploadFile(ctx context.Context, localPath, remoteName, remotePath string) error {
+	args := []string{"copyto", localPath, fmt.Sprintf("%s:%s", remoteName, remotePath)}
+	if e.configPath != "" {
+		args = append(args, "--config", e.configPath)
+	}
+
+	cmd := exec.CommandContext(ctx, "rclone", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rclone copyto failed: %v: %s", err, string(output))
+	}
+
+	return nil
+}
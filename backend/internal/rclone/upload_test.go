@@ -0,0 +1,36 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUploadFile(t *testing.T) {
+	logFile := fakeRcloneLog(t, "exit 0")
+	local := filepath.Join(t.TempDir(), "upload")
+	if err := os.WriteFile(local, []byte("<?php"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewExecutor("/etc/rclone.conf").UploadFile(context.Background(), local, "site", "/var/www/index.php"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := readCalls(t, logFile)
+	want := "copyto " + local + " site:/var/www/index.php --config /etc/rclone.conf"
+	if len(calls) != 1 || calls[0] != want {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestUploadFileFailure(t *testing.T) {
+	writeFakeRclone(t, `echo "directory not found" >&2; exit 3`)
+
+	err := NewExecutor("").UploadFile(context.Background(), "/tmp/upload", "site", "/missing/index.php")
+	if err == nil || !strings.Contains(err.Error(), "directory not found") {
+		t.Errorf("err = %v, want the rclone output", err)
+	}
+}
@@ -0,0 +1,87 @@
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// DetectRcloneVersion returns the installed rclone version (e.g. "v1.67.0")
+func (e *Executor) DetectRcloneVersion(ctx context.Context) (string, error) {
+	// Newer rclone builds can report the version as JSON
+	output, err := exec.CommandContext(ctx, "rclone", "version", "--json").Output()
+	if err == nil {
+		var info struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(output, &info) == nil && info.Version != "" {
+			return info.Version, nil
+		}
+	}
+
+	// Fall back to the plain text format: "rclone v1.67.0"
+	output, err = exec.CommandContext(ctx, "rclone", "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run rclone version: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	fields := strings.Fields(lines[0])
+	if len(fields) < 2 || fields[0] != "rclone" {
+		return "", fmt.Errorf("unexpected rclone version output: %s", lines[0])
+	}
+
+	return fields[1], nil
+}
+
+// CheckRcloneVersion returns an error if detected is older than minimum.
+// Versions are compared as vMajor.Minor.Patch; suffixes like "-beta" are ignored.
+func CheckRcloneVersion(detected, minimum string) error {
+	detectedParts, err := parseVersion(detected)
+	if err != nil {
+		return err
+	}
+	minimumParts, err := parseVersion(minimum)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if detectedParts[i] > minimumParts[i] {
+			return nil
+		}
+		if detectedParts[i] < minimumParts[i] {
+			return fmt.Errorf("rclone %s is older than the minimum supported version v%s", detected, strings.TrimPrefix(minimum, "v"))
+		}
+	}
+
+	return nil
+}
+
+// parseVersion parses "v1.67.0", "1.67" or "v1.68.0-beta.1234" into [major, minor, patch]
+func parseVersion(version string) ([3]int, error) {
+	var parts [3]int
+
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(v, "-+"); idx != -1 {
+		v = v[:idx]
+	}
+
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, fmt.Errorf("invalid version: %s", version)
+	}
+
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, fmt.Errorf("invalid version: %s", version)
+		}
+		parts[i] = n
+	}
+
+	return parts, nil
+}
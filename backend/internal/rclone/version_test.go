@@ -0,0 +1,59 @@
+package rclone
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDetectRcloneVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"json output", `[ "$2" = "--json" ] && echo '{"version":"v1.68.1","os":"linux"}'`, "v1.68.1"},
+		{"plain text fallback", `[ "$2" = "--json" ] && exit 1
+echo "rclone v1.67.0"
+echo "- os/version: debian 12"`, "v1.67.0"},
+	}
+	for _, tt := range tests {
+		writeFakeRclone(t, tt.script)
+		got, err := NewExecutor("").DetectRcloneVersion(context.Background())
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: version = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectRcloneVersionUnexpectedOutput(t *testing.T) {
+	writeFakeRclone(t, `[ "$2" = "--json" ] && exit 1; echo "command not found"`)
+	if version, err := NewExecutor("").DetectRcloneVersion(context.Background()); err == nil {
+		t.Errorf("version = %q, want an error", version)
+	}
+}
+
+func TestCheckRcloneVersion(t *testing.T) {
+	tests := []struct {
+		detected string
+		ok       bool
+	}{
+		{"v1.60.0", true},
+		{"v1.60.1", true},
+		{"v1.61", true},
+		{"v2.0.0", true},
+		{"v1.68.0-beta.8123", true},
+		{"v1.59.2", false},
+		{"v0.99.0", false},
+		{"vX.Y", false},
+	}
+	for _, tt := range tests {
+		err := CheckRcloneVersion(tt.detected, "1.60.0")
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckRcloneVersion(%q, 1.60.0) = %v, want ok %v", tt.detected, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package sshutil
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// DetectSSHAgent returns the socket of the running ssh-agent from SSH_AUTH_SOCK
+// and whether it can be used
+func DetectSSHAgent() (socketPath string, available bool) {
+	socketPath = os.Getenv("SSH_AUTH_SOCK")
+	if socketPath == "" {
+		return "", false
+	}
+	return socketPath, validateAgentSocket(socketPath) == nil
+}
+
+// validateAgentSocket checks that path exists and is a Unix socket
+func validateAgentSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("SSH agent socket not available: %w", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("SSH agent socket %s is not a socket", path)
+	}
+	return nil
+}
+
+// agentAuth connects to the ssh-agent listening on socketPath and returns an
+// auth method using its keys. The connection must stay open until the SSH
+// handshake is done.
+func agentAuth(socketPath string) (ssh.AuthMethod, net.Conn, error) {
+	if err := validateAgentSocket(socketPath); err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to SSH agent: %w", err)
+	}
+
+	agentClient := agent.NewClient(conn)
+	return ssh.PublicKeysCallback(agentClient.Signers), conn, nil
+}
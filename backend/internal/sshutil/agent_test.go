@@ -0,0 +1,175 @@
+package sshutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// startSSHAgent serves an in-memory keyring holding a new key on a Unix
+// socket and returns the socket path and the public key
+func startSSHAgent(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+
+	return socket, signer.PublicKey()
+}
+
+// agentConfig returns a configuration authenticating with the agent only
+func agentConfig(config ConnectionConfig, socket string) ConnectionConfig {
+	config.Password = ""
+	config.SSHAgentSocket = socket
+	return config
+}
+
+func TestAgentAuthentication(t *testing.T) {
+	socket, publicKey := startSSHAgent(t)
+	config := agentConfig(startSFTPServer(t, publicKey), socket)
+
+	client, sshClient, err := CreateSFTPClient(config)
+	if err != nil {
+		t.Fatalf("authentication with the agent key failed: %v", err)
+	}
+	defer sshClient.Close()
+	defer client.Close()
+
+	if _, err := client.Getwd(); err != nil {
+		t.Errorf("SFTP session does not work: %v", err)
+	}
+}
+
+func TestAgentAuthenticationUnknownKey(t *testing.T) {
+	socket, _ := startSSHAgent(t)
+	_, otherKey := startSSHAgent(t)
+	config := agentConfig(startSFTPServer(t, otherKey), socket)
+
+	if _, err := CreateSSHClient(config); err == nil {
+		t.Error("server accepted a key it does not know")
+	}
+}
+
+func TestAgentAuthenticationFromEnvironment(t *testing.T) {
+	socket, publicKey := startSSHAgent(t)
+	t.Setenv("SSH_AUTH_SOCK", socket)
+	config := agentConfig(startSFTPServer(t, publicKey), "")
+
+	sshClient, err := CreateSSHClient(config)
+	if err != nil {
+		t.Fatalf("authentication with SSH_AUTH_SOCK failed: %v", err)
+	}
+	sshClient.Close()
+}
+
+func TestAgentAuthenticationInvalidSocket(t *testing.T) {
+	notSocket := filepath.Join(t.TempDir(), "agent.sock")
+	if err := os.WriteFile(notSocket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := agentConfig(startSFTPServer(t), notSocket)
+
+	_, err := CreateSSHClient(config)
+	if err == nil || !strings.Contains(err.Error(), "is not a socket") {
+		t.Errorf("err = %v, want the invalid socket reported", err)
+	}
+}
+
+func TestValidateAgentSocket(t *testing.T) {
+	socket, _ := startSSHAgent(t)
+	if err := validateAgentSocket(socket); err != nil {
+		t.Errorf("valid socket rejected: %v", err)
+	}
+
+	regular := filepath.Join(t.TempDir(), "agent.sock")
+	if err := os.WriteFile(regular, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateAgentSocket(regular); err == nil || !strings.Contains(err.Error(), "is not a socket") {
+		t.Errorf("regular file: err = %v, want not a socket", err)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.sock")
+	if err := validateAgentSocket(missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing path: err = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestDetectSSHAgent(t *testing.T) {
+	socket, _ := startSSHAgent(t)
+	regular := filepath.Join(t.TempDir(), "agent.sock")
+	if err := os.WriteFile(regular, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		env       string
+		available bool
+	}{
+		{"running agent", socket, true},
+		{"not a socket", regular, false},
+		{"missing socket", filepath.Join(t.TempDir(), "missing.sock"), false},
+		{"no agent", "", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("SSH_AUTH_SOCK", tt.env)
+		path, available := DetectSSHAgent()
+		if path != tt.env || available != tt.available {
+			t.Errorf("%s: DetectSSHAgent() = %q, %v, want %q, %v", tt.name, path, available, tt.env, tt.available)
+		}
+	}
+}
+
+func TestPasswordTakesPrecedenceOverAgent(t *testing.T) {
+	socket, _ := startSSHAgent(t)
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	if got := agentSocket(ConnectionConfig{Password: "secret"}); got != "" {
+		t.Errorf("agentSocket with a password = %q, want none", got)
+	}
+	if got := agentSocket(ConnectionConfig{}); got != socket {
+		t.Errorf("agentSocket = %q, want SSH_AUTH_SOCK", got)
+	}
+	if got := agentSocket(ConnectionConfig{SSHAgentSocket: "/run/agent.sock"}); got != "/run/agent.sock" {
+		t.Errorf("agentSocket = %q, want the configured socket", got)
+	}
+}
@@ -0,0 +1,166 @@
+package sshutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+)
+
+// maxBannerBytes is how much of the server greeting is read when looking for the SSH banner
+const maxBannerBytes = 255
+
+// Auth results reported by Diagnose
+const (
+	AuthResultSuccess          = "success"
+	AuthResultFailed           = "auth_failed"
+	AuthResultSFTPUnavailable  = "sftp_unavailable"
+	AuthResultConnectionFailed = "connection_failed"
+	AuthResultSkipped          = "skipped"
+)
+
+// DiagnoseResult reports each step of a connection diagnostic
+type DiagnoseResult struct {
+	DNSResolved      bool     `json:"dns_resolved"`
+	DNSLatencyMs     float64  `json:"dns_latency_ms"`
+	ResolvedAddrs    []string `json:"resolved_addrs,omitempty"`
+	TCPReachable     bool     `json:"tcp_reachable"`
+	TCPLatencyMs     float64  `json:"tcp_latency_ms"`
+	SSHBannerVisible bool     `json:"ssh_banner_visible"`
+	SSHBanner        string   `json:"ssh_banner,omitempty"`
+	AuthResult       string   `json:"auth_result"`
+	Error            string   `json:"error,omitempty"`
+	Suggestion       string   `json:"suggestion,omitempty"`
+}
+
+// Diagnose checks DNS resolution, TCP reachability, the SSH banner and, when
+// credentials are given, authentication and the SFTP subsystem. Each step only
+// runs if the previous one succeeded. SSH does not allow opening the SFTP
+// subsystem before authenticating, so without credentials that step is skipped.
+func Diagnose(ctx context.Context, config ConnectionConfig) DiagnoseResult {
+	result := DiagnoseResult{AuthResult: AuthResultSkipped}
+	if config.Port == 0 {
+		config.Port = 22
+	}
+
+	// DNS resolution
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, config.Host)
+	result.DNSLatencyMs = elapsedMs(start)
+	if err != nil {
+		result.Error = err.Error()
+		result.Suggestion = suggestionFor(err)
+		return result
+	}
+	result.DNSResolved = true
+	result.ResolvedAddrs = addrs
+
+	// Raw TCP connect
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	dialer := net.Dialer{Timeout: constants.TCPDialTimeout}
+	start = time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	result.TCPLatencyMs = elapsedMs(start)
+	if err != nil {
+		result.Error = err.Error()
+		result.Suggestion = suggestionFor(err)
+		return result
+	}
+	result.TCPReachable = true
+
+	// SSH banner, read before any authentication
+	banner, err := readBanner(conn, config.GetConnectTimeout())
+	conn.Close()
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read server banner: %v", err)
+		result.Suggestion = "The port is open but the server did not send a greeting. Check that SSH/SFTP is running on this port"
+		return result
+	}
+	result.SSHBanner = banner
+	result.SSHBannerVisible = strings.HasPrefix(banner, "SSH-")
+	if !result.SSHBannerVisible {
+		result.Suggestion = "The server on this port does not speak SSH. Check the port (FTP usually uses 21, SSH/SFTP 22)"
+		return result
+	}
+
+	if config.Username == "" {
+		return result
+	}
+
+	// Authentication and SFTP subsystem
+	sftpClient, sshClient, err := CreateSFTPClient(config)
+	if err != nil {
+		result.Error = err.Error()
+		switch {
+		case strings.Contains(err.Error(), "unable to authenticate"):
+			result.AuthResult = AuthResultFailed
+		case strings.Contains(err.Error(), "failed to create SFTP session"):
+			result.AuthResult = AuthResultSFTPUnavailable
+		default:
+			result.AuthResult = AuthResultConnectionFailed
+		}
+		result.Suggestion = suggestionFor(err)
+		return result
+	}
+	sftpClient.Close()
+	sshClient.Close()
+	result.AuthResult = AuthResultSuccess
+
+	return result
+}
+
+// readBanner reads the first line the server sends after the TCP handshake
+func readBanner(conn net.Conn, timeout time.Duration) (string, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, maxBannerBytes)
+	n, err := conn.Read(buf)
+	if n == 0 {
+		if err == nil {
+			err = errors.New("empty banner")
+		}
+		return "", err
+	}
+
+	banner, _, _ := strings.Cut(string(buf[:n]), "\n")
+	return strings.TrimSpace(banner), nil
+}
+
+// suggestionFor maps common connection errors to an actionable hint
+func suggestionFor(err error) string {
+	msg := strings.ToLower(err.Error())
+
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) || strings.Contains(msg, "no such host"):
+		return "The hostname could not be resolved. Check it for typos; for SFTP, use the SSH hostname rather than the FTP one"
+	case strings.Contains(msg, "connection refused"):
+		return "Check that SSH/SFTP is running and the port is correct"
+	case strings.Contains(msg, "i/o timeout") || strings.Contains(msg, "deadline exceeded"):
+		return "The server did not answer in time. A firewall may be blocking the port, or your IP may need to be whitelisted"
+	case strings.Contains(msg, "unable to authenticate"):
+		return "Verify username and password/SSH key"
+	case strings.Contains(msg, "failed to parse ssh key"):
+		return "The SSH key could not be read. Paste the full private key including its BEGIN/END lines"
+	case strings.Contains(msg, "failed to create sftp session"):
+		return "SSH login works but the SFTP subsystem is disabled. Ask the host to enable SFTP or use FTP instead"
+	case strings.Contains(msg, "host key mismatch"):
+		return "The server's host key changed since the last connection. Verify you are connecting to the right server"
+	case strings.Contains(msg, "network is unreachable") || strings.Contains(msg, "no route to host"):
+		return "The server's network is unreachable. Check your internet connection and the host address"
+	default:
+		return ""
+	}
+}
+
+// elapsedMs returns the milliseconds elapsed since start
+func elapsedMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -0,0 +1,119 @@
+package sshutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// TOTP parameters used by authenticator apps (RFC 6238 defaults)
+const (
+	totpStep   = 30 * time.Second
+	totpDigits = 6
+)
+
+// keyboardInteractiveRetries is how many keyboard-interactive rounds are tried,
+// e.g. when a TOTP code was rejected because it rolled over mid-handshake
+const keyboardInteractiveRetries = 2
+
+// usesKeyboardInteractive reports whether the config has answers for
+// keyboard-interactive prompts
+func (c ConnectionConfig) usesKeyboardInteractive() bool {
+	return len(c.KeyboardInteractiveAnswers) > 0 || c.TOTPSecret != ""
+}
+
+// keyboardInteractiveAuth answers keyboard-interactive prompts such as
+// "Password:" and "Verification code:"
+func keyboardInteractiveAuth(config ConnectionConfig) ssh.AuthMethod {
+	challenge := func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i, question := range questions {
+			answer, err := answerQuestion(config, question)
+			if err != nil {
+				return nil, err
+			}
+			answers[i] = answer
+		}
+		return answers, nil
+	}
+	return ssh.RetryableAuthMethod(ssh.KeyboardInteractive(challenge), keyboardInteractiveRetries)
+}
+
+// answerQuestion matches a prompt against KeyboardInteractiveAnswers (longest
+// matching key wins) and falls back to the password and the TOTP code
+func answerQuestion(config ConnectionConfig, question string) (string, error) {
+	prompt := strings.ToLower(strings.TrimSpace(question))
+
+	bestKey := ""
+	for key := range config.KeyboardInteractiveAnswers {
+		if strings.Contains(prompt, strings.ToLower(key)) && len(key) > len(bestKey) {
+			bestKey = key
+		}
+	}
+	if bestKey != "" {
+		return config.KeyboardInteractiveAnswers[bestKey], nil
+	}
+
+	switch {
+	case strings.Contains(prompt, "password"):
+		return config.Password, nil
+	case config.TOTPSecret != "" && isCodePrompt(prompt):
+		return computeTOTP(config.TOTPSecret, time.Now())
+	}
+
+	return "", fmt.Errorf("no answer configured for keyboard-interactive prompt %q", question)
+}
+
+// isCodePrompt reports whether a lowercased prompt asks for a one-time code
+func isCodePrompt(prompt string) bool {
+	for _, word := range []string{"verification code", "code", "token", "otp", "one-time"} {
+		if strings.Contains(prompt, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeTOTPSecret decodes a base32 secret as shown by authenticator setups,
+// ignoring case, spaces and padding
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	normalized = strings.TrimRight(normalized, "=")
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+	return key, nil
+}
+
+// computeTOTP returns the RFC 6238 code (HMAC-SHA1, 30s step, 6 digits) of a
+// base32 secret at the given time
+func computeTOTP(secret string, at time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/int64(totpStep/time.Second)))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226 section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, code%modulo), nil
+}
@@ -0,0 +1,121 @@
+package sshutil
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is base32("12345678901234567890"), the SHA1 key of the
+// RFC 6238 test vectors
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestComputeTOTP(t *testing.T) {
+	// RFC 6238 appendix B SHA1 vectors, truncated to 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := computeTOTP(rfc6238Secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("T=%d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("T=%d: code = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestComputeTOTPNormalizesSecret(t *testing.T) {
+	secret := strings.ToLower("GEZD GNBV GY3T QOJQ GEZD GNBV GY3T QOJQ") + "===="
+	got, err := computeTOTP(secret, time.Unix(59, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "287082" {
+		t.Errorf("code = %s, want 287082", got)
+	}
+
+	if _, err := computeTOTP("not base32!", time.Unix(59, 0)); err == nil {
+		t.Error("expected an error for an invalid secret")
+	}
+}
+
+func TestAnswerQuestion(t *testing.T) {
+	config := ConnectionConfig{
+		Password: "secret",
+		KeyboardInteractiveAnswers: map[string]string{
+			"code":             "generic",
+			"backup code":      "backup",
+			"Backup Code Pin:": "pin",
+		},
+	}
+
+	tests := []struct {
+		question string
+		want     string
+	}{
+		{"Verification code: ", "generic"},
+		{"Enter backup code:", "backup"},
+		{"  backup code pin: ", "pin"},
+		{"Password:", "secret"},
+		{"deploy@example.com's PASSWORD: ", "secret"},
+	}
+	for _, tt := range tests {
+		got, err := answerQuestion(config, tt.question)
+		if err != nil {
+			t.Errorf("%q: %v", tt.question, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: answer = %q, want %q", tt.question, got, tt.want)
+		}
+	}
+
+	if _, err := answerQuestion(config, "Favourite colour?"); err == nil {
+		t.Error("expected an error for an unknown prompt")
+	}
+}
+
+func TestAnswerQuestionTOTPFallback(t *testing.T) {
+	config := ConnectionConfig{Password: "secret", TOTPSecret: rfc6238Secret}
+
+	tests := []struct {
+		question string
+		password bool
+	}{
+		{"Verification code:", false},
+		{"OTP:", false},
+		{"Token: ", false},
+		// A password prompt takes precedence over a code prompt
+		{"One-time password:", true},
+	}
+	for _, tt := range tests {
+		before, _ := computeTOTP(rfc6238Secret, time.Now())
+		got, err := answerQuestion(config, tt.question)
+		after, _ := computeTOTP(rfc6238Secret, time.Now())
+		if err != nil {
+			t.Errorf("%q: %v", tt.question, err)
+			continue
+		}
+		switch {
+		case tt.password && got != "secret":
+			t.Errorf("%q: answer = %q, want the password", tt.question, got)
+		// The step may roll over between the calls
+		case !tt.password && got != before && got != after:
+			t.Errorf("%q: answer = %q, want the current code %s", tt.question, got, before)
+		}
+	}
+
+	if _, err := answerQuestion(ConnectionConfig{}, "Verification code:"); err == nil {
+		t.Error("expected an error without a TOTP secret")
+	}
+}
@@ -0,0 +1,202 @@
+package sshutil
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+)
+
+// ErrPoolClosed is returned by Get once the pool has been closed
+var ErrPoolClosed = errors.New("sftp connection pool is closed")
+
+// SFTPConnectionPool hands out SFTP connections to concurrent workers,
+// opening at most MaxConns connections to the same server
+type SFTPConnectionPool struct {
+	config   ConnectionConfig
+	MaxConns int
+
+	// Throttles new connections; idle connections are reused without waiting.
+	// Defaults to the limiter shared by all pools for the same host:port.
+	ConnectionRateLimit *ConnectionRateLimiter
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	idle    []*sftp.Client
+	clients map[*sftp.Client]*ssh.Client // all open connections and their SSH transport
+	opening int                          // connections currently being dialed
+	closed  bool
+}
+
+// NewSFTPConnectionPool creates a pool for the given server. Connections are
+// opened lazily; maxConns <= 0 uses the default pool size.
+func NewSFTPConnectionPool(config ConnectionConfig, maxConns int) *SFTPConnectionPool {
+	if maxConns <= 0 {
+		maxConns = constants.DefaultSFTPPoolSize
+	}
+
+	pool := &SFTPConnectionPool{
+		config:              config,
+		MaxConns:            maxConns,
+		ConnectionRateLimit: HostConnectionLimiter(hostPort(config)),
+		clients:             make(map[*sftp.Client]*ssh.Client),
+	}
+	pool.cond = sync.NewCond(&pool.mu)
+	return pool
+}
+
+// Get returns an idle connection, opens a new one if the pool is not full,
+// or blocks until another worker returns one with Put. Opening a new
+// connection first waits on ConnectionRateLimit.
+func (p *SFTPConnectionPool) Get() (*sftp.Client, error) {
+	p.mu.Lock()
+	for {
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+		if n := len(p.idle); n > 0 {
+			client := p.idle[n-1]
+			p.idle = p.idle[:n-1]
+			p.mu.Unlock()
+			return client, nil
+		}
+		if len(p.clients)+p.opening < p.MaxConns {
+			break
+		}
+		p.cond.Wait()
+	}
+	p.opening++
+	p.mu.Unlock()
+
+	p.ConnectionRateLimit.Wait()
+	sftpClient, sshClient, err := CreateSFTPClient(p.config)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.opening--
+	if err != nil {
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			log.Printf("WARNING: Authentication to %s failed while opening a pooled connection; if the credentials are correct, the host may be rejecting connections because the rate limit is too high", hostPort(p.config))
+		}
+		p.cond.Signal()
+		return nil, err
+	}
+	if p.closed {
+		sftpClient.Close()
+		sshClient.Close()
+		return nil, ErrPoolClosed
+	}
+	p.clients[sftpClient] = sshClient
+
+	return sftpClient, nil
+}
+
+// Put returns a connection obtained from Get to the pool
+func (p *SFTPConnectionPool) Put(client *sftp.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.clients[client]; !ok {
+		return
+	}
+	if p.closed {
+		p.closeLocked(client)
+		return
+	}
+
+	p.idle = append(p.idle, client)
+	p.cond.Signal()
+}
+
+// Discard closes a connection obtained from Get instead of returning it,
+// e.g. after it failed mid-transfer
+func (p *SFTPConnectionPool) Discard(client *sftp.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.clients[client]; ok {
+		p.closeLocked(client)
+		p.cond.Signal()
+	}
+}
+
+// HealthCheck stats the working directory on every idle connection and
+// closes the ones that fail or do not answer within the operation timeout
+func (p *SFTPConnectionPool) HealthCheck() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	transports := make(map[*sftp.Client]*ssh.Client, len(idle))
+	for _, client := range idle {
+		transports[client] = p.clients[client]
+	}
+	p.mu.Unlock()
+
+	var healthy []*sftp.Client
+	var stale []*sftp.Client
+	for _, client := range idle {
+		err := RunWithTimeout(transports[client], p.config.GetOperationTimeout(), func() error {
+			_, err := client.Stat(".")
+			return err
+		})
+		if err != nil {
+			stale = append(stale, client)
+		} else {
+			healthy = append(healthy, client)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, client := range stale {
+		p.closeLocked(client)
+	}
+	for _, client := range healthy {
+		if p.closed {
+			p.closeLocked(client)
+		} else {
+			p.idle = append(p.idle, client)
+		}
+	}
+	p.cond.Broadcast()
+
+	if len(stale) > 0 {
+		return fmt.Errorf("removed %d stale SFTP connection(s) to %s", len(stale), p.config.Host)
+	}
+	return nil
+}
+
+// Close closes all idle connections. Connections still in use are closed
+// when they are returned with Put.
+func (p *SFTPConnectionPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for _, client := range p.idle {
+		p.closeLocked(client)
+	}
+	p.idle = nil
+	p.cond.Broadcast()
+
+	return nil
+}
+
+// closeLocked closes a connection and forgets it; p.mu must be held.
+// The SSH transport is closed first so the SFTP client does not wait on an
+// unresponsive server.
+func (p *SFTPConnectionPool) closeLocked(client *sftp.Client) {
+	if sshClient := p.clients[client]; sshClient != nil {
+		sshClient.Close()
+	}
+	client.Close()
+	delete(p.clients, client)
+}
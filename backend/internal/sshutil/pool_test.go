@@ -0,0 +1,229 @@
+package sshutil
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSFTPServer serves SFTP on a local port and returns a configuration
+// that connects to it with a password. The server also accepts the given
+// public keys.
+func startSFTPServer(t *testing.T, authorizedKeys ...ssh.PublicKey) ConnectionConfig {
+	t.Helper()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "deploy" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			for _, authorized := range authorizedKeys {
+				if conn.User() == "deploy" && bytes.Equal(key.Marshal(), authorized.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	root := t.TempDir()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTPConn(conn, serverConfig, root)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	ClearHostKeyStore()
+	return ConnectionConfig{
+		Host:     "127.0.0.1",
+		Port:     addr.Port,
+		Username: "deploy",
+		Password: "secret",
+		Timeout:  5 * time.Second,
+	}
+}
+
+func serveSFTPConn(conn net.Conn, config *ssh.ServerConfig, root string) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range channelRequests {
+				isSFTP := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(isSFTP, nil)
+				if !isSFTP {
+					continue
+				}
+				server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(root))
+				if err != nil {
+					channel.Close()
+					return
+				}
+				go func() {
+					server.Serve()
+					server.Close()
+				}()
+			}
+		}()
+	}
+}
+
+// getAsync calls pool.Get in a goroutine
+func getAsync(pool *SFTPConnectionPool) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		client, err := pool.Get()
+		if err == nil {
+			pool.Put(client)
+		}
+		result <- err
+	}()
+	return result
+}
+
+func TestSFTPConnectionPoolBlocksWhenFull(t *testing.T) {
+	pool := NewSFTPConnectionPool(startSFTPServer(t), 3)
+	pool.ConnectionRateLimit = nil
+	defer pool.Close()
+
+	var clients []*sftp.Client
+	for i := 0; i < 3; i++ {
+		client, err := pool.Get()
+		if err != nil {
+			t.Fatalf("Get %d: %v", i+1, err)
+		}
+		if _, err := client.Getwd(); err != nil {
+			t.Fatalf("connection %d does not work: %v", i+1, err)
+		}
+		clients = append(clients, client)
+	}
+
+	fourth := getAsync(pool)
+	select {
+	case err := <-fourth:
+		t.Fatalf("4th Get returned %v while the pool was full", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	pool.Put(clients[0])
+	select {
+	case err := <-fourth:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("4th Get still blocked after a Put")
+	}
+
+	pool.mu.Lock()
+	open := len(pool.clients)
+	pool.mu.Unlock()
+	if open != 3 {
+		t.Errorf("%d connections open, want the 3 reused", open)
+	}
+}
+
+func TestSFTPConnectionPoolDiscardFreesSlot(t *testing.T) {
+	pool := NewSFTPConnectionPool(startSFTPServer(t), 1)
+	pool.ConnectionRateLimit = nil
+	defer pool.Close()
+
+	client, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiting := getAsync(pool)
+	pool.Discard(client)
+
+	select {
+	case err := <-waiting:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get still blocked after a Discard")
+	}
+}
+
+func TestSFTPConnectionPoolClose(t *testing.T) {
+	pool := NewSFTPConnectionPool(startSFTPServer(t), 1)
+	pool.ConnectionRateLimit = nil
+
+	client, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiting := getAsync(pool)
+	time.Sleep(50 * time.Millisecond)
+	pool.Close()
+
+	select {
+	case err := <-waiting:
+		if !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("blocked Get = %v, want ErrPoolClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get still blocked after Close")
+	}
+
+	// Connections in use are closed when they come back
+	pool.Put(client)
+	if _, err := client.Getwd(); err == nil {
+		t.Error("connection returned after Close is still open")
+	}
+	if _, err := pool.Get(); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Get after Close = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestHostPort(t *testing.T) {
+	if got := hostPort(ConnectionConfig{Host: "example.com"}); got != "example.com:22" {
+		t.Errorf("hostPort = %q, want example.com:22", got)
+	}
+	if got := hostPort(ConnectionConfig{Host: "::1", Port: 2222}); got != "[::1]:2222" {
+		t.Errorf("hostPort = %q, want [::1]:2222", got)
+	}
+}
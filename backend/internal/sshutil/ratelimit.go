@@ -0,0 +1,115 @@
+package sshutil
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+)
+
+// ConnectionRateLimiter limits how many new connections are opened per
+// minute. It is a token bucket: up to perMinute connections may be opened at
+// once, after which they are spaced evenly over the minute. A nil limiter
+// does not limit anything.
+type ConnectionRateLimiter struct {
+	mu       sync.Mutex
+	burst    float64
+	interval time.Duration // time to earn back one connection
+	tokens   float64
+	last     time.Time
+}
+
+// NewConnectionRateLimiter creates a limiter allowing perMinute new
+// connections per minute; perMinute <= 0 returns nil (no limit)
+func NewConnectionRateLimiter(perMinute int) *ConnectionRateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &ConnectionRateLimiter{
+		burst:    float64(perMinute),
+		interval: time.Minute / time.Duration(perMinute),
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a new connection may be opened
+func (l *ConnectionRateLimiter) Wait() {
+	if delay := l.reserve(time.Now()); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// reserve takes one connection from the bucket and returns how long the
+// caller has to wait before using it
+func (l *ConnectionRateLimiter) reserve(now time.Time) time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += float64(elapsed) / float64(l.interval)
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * float64(l.interval))
+}
+
+// Per-host limiters, shared by every pool connecting to the same host:port
+var (
+	hostLimitersMu       sync.Mutex
+	hostLimiters         = make(map[string]*ConnectionRateLimiter)
+	hostConnectionLimits = make(map[string]int)
+)
+
+// SetHostConnectionLimits overrides the number of new connections per minute
+// allowed to the given "host:port" keys. Hosts without an override use
+// constants.DefaultMaxNewConnectionsPerMinute.
+func SetHostConnectionLimits(limits map[string]int) {
+	hostLimitersMu.Lock()
+	defer hostLimitersMu.Unlock()
+
+	for hostPort, perMinute := range limits {
+		hostConnectionLimits[hostPort] = perMinute
+		delete(hostLimiters, hostPort)
+	}
+}
+
+// HostConnectionLimiter returns the limiter shared by all connections to hostPort
+func HostConnectionLimiter(hostPort string) *ConnectionRateLimiter {
+	hostLimitersMu.Lock()
+	defer hostLimitersMu.Unlock()
+
+	if limiter, ok := hostLimiters[hostPort]; ok {
+		return limiter
+	}
+
+	perMinute, ok := hostConnectionLimits[hostPort]
+	if !ok {
+		perMinute = constants.DefaultMaxNewConnectionsPerMinute
+	}
+	limiter := NewConnectionRateLimiter(perMinute)
+	hostLimiters[hostPort] = limiter
+	return limiter
+}
+
+// hostPort returns the "host:port" key of a connection, defaulting to port 22
+func hostPort(config ConnectionConfig) string {
+	port := config.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(config.Host, strconv.Itoa(port))
+}
@@ -0,0 +1,102 @@
+package sshutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnectionRateLimiterThrottles(t *testing.T) {
+	limiter := NewConnectionRateLimiter(6) // one connection every 10s after the burst
+	now := limiter.last
+
+	// The burst is available immediately
+	for i := 0; i < 6; i++ {
+		if delay := limiter.reserve(now); delay != 0 {
+			t.Fatalf("connection %d delayed by %s within the burst", i+1, delay)
+		}
+	}
+
+	// Further connections queue up one interval apart
+	for i, want := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second} {
+		if delay := limiter.reserve(now); delay != want {
+			t.Errorf("connection %d delayed by %s, want %s", i+7, delay, want)
+		}
+	}
+
+	// Tokens are earned back over time, but only up to the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 6; i++ {
+		if delay := limiter.reserve(now); delay != 0 {
+			t.Fatalf("connection %d delayed by %s after a long pause", i+1, delay)
+		}
+	}
+	if delay := limiter.reserve(now); delay != 10*time.Second {
+		t.Errorf("connection after the refilled burst delayed by %s, want 10s", delay)
+	}
+}
+
+func TestConnectionRateLimiterRefill(t *testing.T) {
+	limiter := NewConnectionRateLimiter(60) // one connection per second
+	now := limiter.last
+	for i := 0; i < 60; i++ {
+		limiter.reserve(now)
+	}
+
+	if delay := limiter.reserve(now.Add(500 * time.Millisecond)); delay != 500*time.Millisecond {
+		t.Errorf("delay = %s, want the remaining 500ms", delay)
+	}
+	if delay := limiter.reserve(now.Add(3 * time.Second)); delay != 0 {
+		t.Errorf("delay = %s after earning back connections, want none", delay)
+	}
+}
+
+func TestConnectionRateLimiterWait(t *testing.T) {
+	limiter := NewConnectionRateLimiter(600) // one connection every 100ms
+	for i := 0; i < 600; i++ {
+		limiter.Wait()
+	}
+
+	start := time.Now()
+	limiter.Wait()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Wait returned after %s once the burst was used up", elapsed)
+	}
+}
+
+func TestConnectionRateLimiterDisabled(t *testing.T) {
+	limiter := NewConnectionRateLimiter(0)
+	if limiter != nil {
+		t.Fatal("a limit of 0 should disable the limiter")
+	}
+	for i := 0; i < 1000; i++ {
+		if delay := limiter.reserve(time.Now()); delay != 0 {
+			t.Fatalf("nil limiter delayed by %s", delay)
+		}
+	}
+}
+
+func TestHostConnectionLimiter(t *testing.T) {
+	SetHostConnectionLimits(map[string]int{"limited.example:22": 2, "unlimited.example:22": 0})
+
+	shared := HostConnectionLimiter("limited.example:22")
+	if shared == nil || shared != HostConnectionLimiter("limited.example:22") {
+		t.Fatal("pools to the same host do not share a limiter")
+	}
+	if shared.burst != 2 {
+		t.Errorf("burst = %v, want the configured 2", shared.burst)
+	}
+	if HostConnectionLimiter("unlimited.example:22") != nil {
+		t.Error("a limit of 0 should disable the limiter")
+	}
+
+	other := HostConnectionLimiter("other.example:2222")
+	if other == shared {
+		t.Error("different hosts share a limiter")
+	}
+
+	// Changing the limit replaces the limiter
+	SetHostConnectionLimits(map[string]int{"limited.example:22": 5})
+	if replaced := HostConnectionLimiter("limited.example:22"); replaced == shared || replaced.burst != 5 {
+		t.Error("limiter not replaced after the limit changed")
+	}
+}
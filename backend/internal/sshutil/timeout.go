@@ -0,0 +1,37 @@
+package sshutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrOperationTimeout is returned when an SFTP operation exceeds its timeout
+var ErrOperationTimeout = errors.New("sftp operation timed out")
+
+// RunWithTimeout runs a blocking SFTP operation and gives up after timeout.
+// pkg/sftp has no cancellation API, so on timeout conn is closed to unblock
+// the operation. conn should be the *ssh.Client under the SFTP client: closing
+// the SFTP client itself waits for the server and can hang just as long.
+// Neither client can be used after a timeout.
+func RunWithTimeout(conn io.Closer, timeout time.Duration, op func() error) error {
+	// Buffered so the operation goroutine can always finish, even after a timeout
+	result := make(chan error, 1)
+	go func() {
+		result <- op()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		// If the operation completed in the meantime its result is dropped;
+		// the connection is closed either way so callers see a consistent state
+		conn.Close()
+		return fmt.Errorf("%w after %s", ErrOperationTimeout, timeout)
+	}
+}
@@ -0,0 +1,77 @@
+package sshutil
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// closer records whether it was closed and unblocks the pending operation
+type closer struct {
+	closed  atomic.Bool
+	release chan struct{}
+}
+
+func (c *closer) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		close(c.release)
+	}
+	return nil
+}
+
+func TestRunWithTimeoutFires(t *testing.T) {
+	conn := &closer{release: make(chan struct{})}
+
+	start := time.Now()
+	err := RunWithTimeout(conn, 50*time.Millisecond, func() error {
+		<-conn.release // blocks like an SFTP call on a dead server
+		return errors.New("connection closed")
+	})
+
+	if !errors.Is(err, ErrOperationTimeout) {
+		t.Fatalf("err = %v, want ErrOperationTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("returned after %s", elapsed)
+	}
+	if !conn.closed.Load() {
+		t.Error("connection was not closed on timeout")
+	}
+}
+
+func TestRunWithTimeoutReturnsResult(t *testing.T) {
+	conn := &closer{release: make(chan struct{})}
+	want := errors.New("permission denied")
+
+	if err := RunWithTimeout(conn, time.Second, func() error { return want }); err != want {
+		t.Errorf("err = %v, want %v", err, want)
+	}
+	if err := RunWithTimeout(conn, time.Second, func() error { return nil }); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+	if conn.closed.Load() {
+		t.Error("connection closed although the operation finished in time")
+	}
+}
+
+func TestRunWithTimeoutUnblocksSFTPOperation(t *testing.T) {
+	client, sshClient, err := CreateSFTPClient(startSFTPServer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// A zero timeout fires before the server can answer
+	err = RunWithTimeout(sshClient, 0, func() error {
+		time.Sleep(50 * time.Millisecond)
+		_, err := client.Stat(".")
+		return err
+	})
+	if !errors.Is(err, ErrOperationTimeout) {
+		t.Fatalf("err = %v, want ErrOperationTimeout", err)
+	}
+	if _, err := client.Stat("."); err == nil {
+		t.Error("connection is still usable after a timeout")
+	}
+}
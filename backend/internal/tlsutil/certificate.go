@@ -0,0 +1,87 @@
+// Package tlsutil inspects the TLS certificates of the websites being migrated
+package tlsutil
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+)
+
+// ExpiryWarningDays is how many days before expiry a certificate is reported as expiring soon
+const ExpiryWarningDays = 30
+
+// SSLInfo describes the certificate served by a host
+type SSLInfo struct {
+	CommonName      string    `json:"common_name"`
+	Issuer          string    `json:"issuer"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+	IsExpired       bool      `json:"is_expired"`
+	IsSelfSigned    bool      `json:"is_self_signed"`
+	SANs            []string  `json:"sans,omitempty"`
+}
+
+// ExpiringSoon reports whether the certificate expires within ExpiryWarningDays
+func (i *SSLInfo) ExpiringSoon() bool {
+	return i.DaysUntilExpiry < ExpiryWarningDays
+}
+
+// CheckSSLCertificate connects to host:port and returns the certificate it
+// serves. The chain is not verified, so expired and self-signed certificates
+// can be reported instead of failing the handshake.
+func CheckSSLCertificate(host string, port int) (*SSLInfo, error) {
+	if port == 0 {
+		port = 443
+	}
+
+	dialer := &net.Dialer{Timeout: constants.DefaultConnectionTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, strconv.Itoa(port)), &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("TLS connection to %s:%d failed: %w", host, port, err)
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s:%d did not present a certificate", host, port)
+	}
+
+	return certificateInfo(certs[0], time.Now()), nil
+}
+
+// certificateInfo extracts the SSLInfo of a certificate at the given time
+func certificateInfo(cert *x509.Certificate, now time.Time) *SSLInfo {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	return &SSLInfo{
+		CommonName:      cert.Subject.CommonName,
+		Issuer:          cert.Issuer.String(),
+		NotAfter:        cert.NotAfter,
+		DaysUntilExpiry: int(cert.NotAfter.Sub(now).Hours() / 24),
+		IsExpired:       now.After(cert.NotAfter),
+		IsSelfSigned:    isSelfSigned(cert),
+		SANs:            sans,
+	}
+}
+
+// isSelfSigned reports whether cert is signed by its own key. CheckSignatureFrom
+// is not used as it rejects parents that are not CAs, which is typical of
+// self-signed server certificates.
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
@@ -0,0 +1,10 @@
+// Package util holds small helpers shared across backend packages
+package util
+
+import "github.com/google/uuid"
+
+// GenerateJobID returns a new unique job ID made of prefix and a random UUID v4,
+// e.g. "mig-0b7e5c6a-...". Use one of the constants.*JobIDPrefix prefixes.
+func GenerateJobID(prefix string) string {
+	return prefix + uuid.New().String()
+}
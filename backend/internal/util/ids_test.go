@@ -0,0 +1,62 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gonzague/website-mover/backend/internal/constants"
+)
+
+var uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestGenerateJobID(t *testing.T) {
+	prefixes := []string{
+		constants.ScanJobIDPrefix,
+		constants.PlanJobIDPrefix,
+		constants.TransferJobIDPrefix,
+		constants.MigrationJobIDPrefix,
+		constants.VerifyJobIDPrefix,
+		constants.BatchJobIDPrefix,
+	}
+
+	for _, prefix := range prefixes {
+		id := GenerateJobID(prefix)
+		rest, ok := strings.CutPrefix(id, prefix)
+		if !ok {
+			t.Errorf("GenerateJobID(%q) = %q, missing the prefix", prefix, id)
+			continue
+		}
+		if !uuidV4Pattern.MatchString(rest) {
+			t.Errorf("GenerateJobID(%q) = %q, not followed by a UUID v4", prefix, id)
+		}
+	}
+}
+
+func TestGenerateJobIDConcurrentUnique(t *testing.T) {
+	const count = 1000
+
+	ids := make(chan string, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids <- GenerateJobID(constants.MigrationJobIDPrefix)
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool, count)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate job ID %s", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != count {
+		t.Errorf("got %d IDs, want %d", len(seen), count)
+	}
+}
@@ -0,0 +1,34 @@
+package util
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that correlates an API request between the
+// browser and the server logs
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// RequestID returns the client-provided ID when it is a valid UUID, and a new
+// UUID otherwise
+func RequestID(provided string) string {
+	if id, err := uuid.Parse(provided); err == nil {
+		return id.String()
+	}
+	return uuid.New().String()
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// GetRequestID returns the request ID stored in ctx, or "" when there is none
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	const valid = "0b7f3c2e-5d1a-4c8b-9e6f-2a3b4c5d6e7f"
+	if got := RequestID(valid); got != valid {
+		t.Errorf("RequestID(%q) = %q, want it echoed", valid, got)
+	}
+
+	for _, provided := range []string{"", "abc", "0b7f3c2e\nINFO forged log line"} {
+		got := RequestID(provided)
+		if got == provided || !uuidV4Pattern.MatchString(got) {
+			t.Errorf("RequestID(%q) = %q, want a new UUIDv4", provided, got)
+		}
+	}
+}
+
+func TestRequestIDContext(t *testing.T) {
+	if id := GetRequestID(context.Background()); id != "" {
+		t.Errorf("GetRequestID without an ID = %q, want empty", id)
+	}
+	ctx := WithRequestID(context.Background(), "req-1")
+	if id := GetRequestID(ctx); id != "req-1" {
+		t.Errorf("GetRequestID = %q, want req-1", id)
+	}
+}
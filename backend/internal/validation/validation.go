@@ -0,0 +1,191 @@
+// Package validation provides semantic validation of API inputs before they
+// are turned into rclone commands
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gonzague/website-mover/backend/internal/rclone"
+)
+
+// MaxParallelism is the upper bound for rclone --transfers and --checkers
+const MaxParallelism = 64
+
+// remoteNamePattern matches names accepted for rclone remotes
+var remoteNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidationError describes an invalid field
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidateMigrationOptions checks that migration options are consistent
+// before they are passed to the executor
+func ValidateMigrationOptions(opts rclone.MigrationOptions) error {
+	if err := validateRemoteName("source_remote", opts.SourceRemote); err != nil {
+		return err
+	}
+	if err := validateRemoteName("dest_remote", opts.DestRemote); err != nil {
+		return err
+	}
+
+	if err := validatePath("source_path", opts.SourcePath); err != nil {
+		return err
+	}
+	if err := validatePath("dest_path", opts.DestPath); err != nil {
+		return err
+	}
+
+	if opts.Transfers < 0 || opts.Transfers > MaxParallelism {
+		return &ValidationError{Field: "transfers", Message: fmt.Sprintf("must be between 0 and %d", MaxParallelism)}
+	}
+	if opts.Checkers < 0 || opts.Checkers > MaxParallelism {
+		return &ValidationError{Field: "checkers", Message: fmt.Sprintf("must be between 0 and %d", MaxParallelism)}
+	}
+
+	if _, err := rclone.ParseBandwidthLimit(opts.BandwidthLimit); err != nil {
+		return &ValidationError{Field: "bandwidth_limit", Message: err.Error()}
+	}
+
+	if opts.ExcludeModifiedWithin != "" {
+		within, err := time.ParseDuration(opts.ExcludeModifiedWithin)
+		if err != nil || within <= 0 {
+			return &ValidationError{Field: "exclude_modified_within", Message: fmt.Sprintf("invalid duration %q, expected e.g. \"30m\" or \"24h\"", opts.ExcludeModifiedWithin)}
+		}
+	}
+
+	if opts.EmailConfig != nil && opts.EmailConfig.To != "" {
+		if err := ValidateEmailConfig(*opts.EmailConfig); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case opts.ConflictResolution == rclone.ConflictLarger:
+		return &ValidationError{Field: "conflict_resolution", Message: "\"larger\" is not supported by rclone"}
+	case !rclone.ConflictResolutionSupported(opts.ConflictResolution):
+		return &ValidationError{Field: "conflict_resolution", Message: fmt.Sprintf("unknown mode %q, expected overwrite, skip, newer or rename", opts.ConflictResolution)}
+	}
+
+	if opts.SingleFileMode {
+		if opts.DeleteExtraneous {
+			return &ValidationError{Field: "delete_extraneous", Message: "cannot be used with single_file_mode"}
+		}
+		if err := validateFilePath("source_path", opts.SourcePath); err != nil {
+			return err
+		}
+		if err := validateFilePath("dest_path", opts.DestPath); err != nil {
+			return err
+		}
+	}
+
+	if opts.MaxFiles < 0 {
+		return &ValidationError{Field: "max_files", Message: "must not be negative"}
+	}
+	if opts.MaxTotalBytes < 0 {
+		return &ValidationError{Field: "max_total_bytes", Message: "must not be negative"}
+	}
+
+	if err := validateExtensions("include_extensions", opts.IncludeExtensions); err != nil {
+		return err
+	}
+	if err := validateExtensions("exclude_extensions", opts.ExcludeExtensions); err != nil {
+		return err
+	}
+
+	// Copying a directory into itself (or its parent) would overwrite the source
+	if opts.SourceRemote == opts.DestRemote && pathsOverlap(opts.SourcePath, opts.DestPath) {
+		return &ValidationError{Field: "dest_path", Message: "source and destination overlap on the same remote"}
+	}
+
+	return nil
+}
+
+// ValidateBatchMigrationOptions checks the options of a migration to several
+// destinations; each destination must be a valid single migration
+func ValidateBatchMigrationOptions(opts rclone.MigrationOptions) error {
+	if len(opts.DestRemotes) == 0 {
+		return &ValidationError{Field: "dest_remotes", Message: "at least one destination is required"}
+	}
+
+	seen := make(map[rclone.DestinationSpec]bool)
+	for i, dest := range opts.DestRemotes {
+		if seen[dest] {
+			return &ValidationError{Field: fmt.Sprintf("dest_remotes[%d]", i), Message: fmt.Sprintf("duplicate destination %s:%s", dest.Remote, dest.Path)}
+		}
+		seen[dest] = true
+
+		if err := ValidateMigrationOptions(opts.ForDestination(dest)); err != nil {
+			return fmt.Errorf("dest_remotes[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func validateRemoteName(field, name string) error {
+	if name == "" {
+		return &ValidationError{Field: field, Message: "is required"}
+	}
+	if !remoteNamePattern.MatchString(name) {
+		return &ValidationError{Field: field, Message: "only letters, digits, '_' and '-' are allowed"}
+	}
+	return nil
+}
+
+func validatePath(field, path string) error {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		return &ValidationError{Field: field, Message: "must be an absolute path"}
+	}
+	return nil
+}
+
+// validateFilePath checks that path names a file rather than a directory
+func validateFilePath(field, path string) error {
+	if path == "" || strings.HasSuffix(path, "/") {
+		return &ValidationError{Field: field, Message: "must be a file path in single file mode"}
+	}
+	return nil
+}
+
+func validateExtensions(field string, extensions []string) error {
+	for _, ext := range extensions {
+		if len(ext) < 2 || !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext, "/*?[]{}") {
+			return &ValidationError{Field: field, Message: fmt.Sprintf("invalid extension %q: must start with a dot, e.g. \".jpg\"", ext)}
+		}
+	}
+	return nil
+}
+
+// pathsOverlap reports whether one path is equal to or nested inside the other
+func pathsOverlap(a, b string) bool {
+	a = strings.TrimSuffix(a, "/") + "/"
+	b = strings.TrimSuffix(b, "/") + "/"
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// ValidateEmailConfig checks that notification emails can be addressed and sent
+func ValidateEmailConfig(cfg rclone.EmailConfig) error {
+	if cfg.SMTP == "" {
+		return &ValidationError{Field: "email_config.smtp", Message: "is required"}
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return &ValidationError{Field: "email_config.port", Message: "must be between 0 and 65535"}
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return &ValidationError{Field: "email_config.from", Message: fmt.Sprintf("invalid address %q", cfg.From)}
+	}
+	if _, err := mail.ParseAddressList(cfg.To); err != nil {
+		return &ValidationError{Field: "email_config.to", Message: fmt.Sprintf("invalid address list %q", cfg.To)}
+	}
+	return nil
+}
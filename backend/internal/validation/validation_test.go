@@ -0,0 +1,156 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gonzague/website-mover/backend/internal/rclone"
+)
+
+// validOptions returns options that pass validation
+func validOptions() rclone.MigrationOptions {
+	return rclone.MigrationOptions{
+		SourceRemote: "old",
+		SourcePath:   "/var/www",
+		DestRemote:   "new",
+		DestPath:     "/srv/www",
+	}
+}
+
+func TestValidateMigrationOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(opts *rclone.MigrationOptions)
+		field  string // empty when the options are valid
+	}{
+		{"valid", func(opts *rclone.MigrationOptions) {}, ""},
+		{"missing source remote", func(opts *rclone.MigrationOptions) { opts.SourceRemote = "" }, "source_remote"},
+		{"invalid dest remote", func(opts *rclone.MigrationOptions) { opts.DestRemote = "new:site" }, "dest_remote"},
+		{"relative source path", func(opts *rclone.MigrationOptions) { opts.SourcePath = "var/www" }, "source_path"},
+		{"relative dest path", func(opts *rclone.MigrationOptions) { opts.DestPath = "../www" }, "dest_path"},
+		{"empty path is the remote root", func(opts *rclone.MigrationOptions) { opts.SourcePath = "" }, ""},
+		{"max transfers", func(opts *rclone.MigrationOptions) { opts.Transfers = MaxParallelism }, ""},
+		{"too many transfers", func(opts *rclone.MigrationOptions) { opts.Transfers = MaxParallelism + 1 }, "transfers"},
+		{"negative checkers", func(opts *rclone.MigrationOptions) { opts.Checkers = -1 }, "checkers"},
+		{"too many checkers", func(opts *rclone.MigrationOptions) { opts.Checkers = MaxParallelism + 1 }, "checkers"},
+		{"invalid bandwidth limit", func(opts *rclone.MigrationOptions) { opts.BandwidthLimit = "fast" }, "bandwidth_limit"},
+		{"invalid exclude window", func(opts *rclone.MigrationOptions) { opts.ExcludeModifiedWithin = "1 day" }, "exclude_modified_within"},
+		{"negative exclude window", func(opts *rclone.MigrationOptions) { opts.ExcludeModifiedWithin = "-1h" }, "exclude_modified_within"},
+		{"conflict mode newer", func(opts *rclone.MigrationOptions) { opts.ConflictResolution = rclone.ConflictNewer }, ""},
+		{"conflict mode larger", func(opts *rclone.MigrationOptions) { opts.ConflictResolution = rclone.ConflictLarger }, "conflict_resolution"},
+		{"unknown conflict mode", func(opts *rclone.MigrationOptions) { opts.ConflictResolution = "merge" }, "conflict_resolution"},
+		{"single file", func(opts *rclone.MigrationOptions) {
+			opts.SingleFileMode = true
+			opts.SourcePath = "/var/www/index.php"
+			opts.DestPath = "/srv/www/index.php"
+		}, ""},
+		{"single file with delete extraneous", func(opts *rclone.MigrationOptions) {
+			opts.SingleFileMode = true
+			opts.DeleteExtraneous = true
+			opts.SourcePath = "/var/www/index.php"
+			opts.DestPath = "/srv/www/index.php"
+		}, "delete_extraneous"},
+		{"single file with directory source", func(opts *rclone.MigrationOptions) {
+			opts.SingleFileMode = true
+			opts.SourcePath = "/var/www/"
+			opts.DestPath = "/srv/www/index.php"
+		}, "source_path"},
+		{"negative max files", func(opts *rclone.MigrationOptions) { opts.MaxFiles = -1 }, "max_files"},
+		{"negative max bytes", func(opts *rclone.MigrationOptions) { opts.MaxTotalBytes = -1 }, "max_total_bytes"},
+		{"valid extensions", func(opts *rclone.MigrationOptions) { opts.IncludeExtensions = []string{".jpg", ".png"} }, ""},
+		{"extension without dot", func(opts *rclone.MigrationOptions) { opts.IncludeExtensions = []string{"jpg"} }, "include_extensions"},
+		{"extension with glob", func(opts *rclone.MigrationOptions) { opts.ExcludeExtensions = []string{".*"} }, "exclude_extensions"},
+		{"bare dot extension", func(opts *rclone.MigrationOptions) { opts.ExcludeExtensions = []string{"."} }, "exclude_extensions"},
+		{"same remote, separate paths", func(opts *rclone.MigrationOptions) {
+			opts.DestRemote = opts.SourceRemote
+			opts.DestPath = "/var/www2"
+		}, ""},
+		{"same remote, nested paths", func(opts *rclone.MigrationOptions) {
+			opts.DestRemote = opts.SourceRemote
+			opts.DestPath = "/var/www/backup"
+		}, "dest_path"},
+		{"same remote, root source", func(opts *rclone.MigrationOptions) {
+			opts.DestRemote = opts.SourceRemote
+			opts.SourcePath = ""
+		}, "dest_path"},
+	}
+
+	for _, tt := range tests {
+		opts := validOptions()
+		tt.modify(&opts)
+		err := ValidateMigrationOptions(opts)
+
+		if tt.field == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s: err = %v, want a ValidationError on %s", tt.name, err, tt.field)
+			continue
+		}
+		if validationErr.Field != tt.field {
+			t.Errorf("%s: error on %s (%s), want %s", tt.name, validationErr.Field, validationErr.Message, tt.field)
+		}
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{"", true},
+		{"/", true},
+		{"/var/www", true},
+		{"var/www", false},
+		{"./www", false},
+		{"~/www", false},
+	}
+	for _, tt := range tests {
+		err := validatePath("path", tt.path)
+		if (err == nil) != tt.valid {
+			t.Errorf("validatePath(%q) = %v, want valid %v", tt.path, err, tt.valid)
+		}
+	}
+}
+
+func TestPathsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"", "", true},
+		{"", "/var/www", true},
+		{"/", "/var/www", true},
+		{"/", "", true},
+		{"/var/www", "/var/www/", true},
+		{"/var/www", "/var/www/backup", true},
+		{"/var/www/backup", "/var/www", true},
+		{"/var/www", "/var/www2", false},
+		{"/var/www", "/srv/www", false},
+	}
+	for _, tt := range tests {
+		if got := pathsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("pathsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestValidateBatchMigrationOptions(t *testing.T) {
+	opts := validOptions()
+	opts.DestRemotes = []rclone.DestinationSpec{
+		{Remote: "new", Path: "/srv/www"},
+		{Remote: "new", Path: "/srv/www"},
+	}
+	if err := ValidateBatchMigrationOptions(opts); err == nil {
+		t.Error("expected an error for duplicate destinations")
+	}
+
+	opts.DestRemotes = nil
+	if err := ValidateBatchMigrationOptions(opts); err == nil {
+		t.Error("expected an error without destinations")
+	}
+}
@@ -8,6 +8,7 @@ export interface Remote {
   password?: string;
   port: number;
   key_file?: string;
+  key_pem?: string;
   // S3-specific fields
   provider?: string;
   access_key_id?: string;
@@ -30,12 +31,56 @@ export interface MigrationOptions {
   bandwidth_limit?: string;
   dry_run: boolean;
   delete_extraneous: boolean;
+  include_extensions?: string[];
+  exclude_extensions?: string[];
+  incremental_since?: string;
+  exclude_modified_within?: string;
+  email_config?: EmailConfig;
+  dest_remotes?: DestinationSpec[];
+  single_file_mode?: boolean;
+  conflict_resolution?: 'overwrite' | 'skip' | 'newer' | 'rename';
+  max_files?: number;
+  max_total_bytes?: number;
+  max_files_check?: boolean;
+  max_bytes_check?: boolean;
+  ignore_errors?: boolean;
+  cleanup_on_failure?: boolean;
+}
+
+export interface DestinationSpec {
+  remote: string;
+  path: string;
+}
+
+export interface EmailConfig {
+  smtp: string;
+  port: number;
+  username?: string;
+  password?: string;
+  from: string;
+  to: string;
+  tls: boolean;
+}
+
+export interface LimitCheck {
+  actual_files: number;
+  max_files?: number;
+  actual_bytes: number;
+  max_bytes?: number;
+  files_exceeded: boolean;
+  bytes_exceeded: boolean;
 }
 
 export interface MigrationJob {
   job_id: string;
   command: string;
   status: string;
+  size_check?: LimitCheck | null;
+}
+
+export interface IncrementalMigrationJob extends MigrationJob {
+  incremental_since: string | null;
+  estimated_files: number;
 }
 
 export interface MigrationHistory {
@@ -50,6 +95,41 @@ export interface MigrationHistory {
   total_bytes?: number;
   total_files?: number;
   transfer_speed?: string;
+  incremental_since?: string;
+  replay_of_id?: string;
+  retry_of_id?: string;
+  retry_root_id?: string;
+  retry_count?: number;
+  batch_id?: string;
+  verify_job_id?: string;
+  verification?: CheckResult;
+  cleanup?: CleanupResult;
+}
+
+export interface CleanupResult {
+  files_deleted: number;
+  bytes_freed: number;
+  errors?: string[];
+}
+
+export interface CheckResult {
+  matched: number;
+  differ: number;
+  missing_dest: number;
+  extra_dest: number;
+  errors: number;
+  differing_files: string[];
+  missing_files: string[];
+}
+
+export interface VerifyJob {
+  id: string;
+  migration_id: string;
+  status: 'running' | 'completed' | 'failed';
+  start_time: string;
+  end_time?: string;
+  result?: CheckResult;
+  error?: string;
 }
 
 export interface TestResult {
@@ -89,15 +169,46 @@ export async function deleteRemote(name: string): Promise<void> {
 }
 
 export async function testRemote(remoteName: string, path: string): Promise<TestResult> {
-  const response = await fetch(`${API_BASE}/remotes/test`, {
+  const response = await fetch(`${API_BASE}/remotes/${remoteName}/test`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({
-      remote_name: remoteName,
-      path: path,
-    }),
+    body: JSON.stringify({ path }),
   });
 
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export interface RemoteHealth {
+  name: string;
+  success: boolean;
+  message: string;
+  error?: string;
+  duration_ms: number;
+  tested_at: string;
+}
+
+export interface HealthReport {
+  results: RemoteHealth[];
+  total: number;
+  ok: number;
+  failed: number;
+  tested_at: string;
+}
+
+export async function testAllRemotes(force = false): Promise<HealthReport> {
+  const query = force ? '?force=true' : '';
+  const response = await fetch(`${API_BASE}/remotes/test-all${query}`, {
+    method: 'POST',
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
   return await response.json();
 }
 
@@ -105,8 +216,13 @@ export interface FileItem {
   name: string;
   is_dir: boolean;
   size: number;
+  mod_time?: string;
+  mime_type?: string;
+  id?: string;
 }
 
+export type FileSortField = 'name' | 'size' | 'mod_time';
+
 export async function listPath(remoteName: string, path: string): Promise<FileItem[]> {
   const params = new URLSearchParams({ path });
   const response = await fetch(`${API_BASE}/remotes/${remoteName}/list?${params}`);
@@ -119,6 +235,85 @@ export async function listPath(remoteName: string, path: string): Promise<FileIt
   return data.items || [];
 }
 
+export async function browseRich(
+  remoteName: string,
+  path: string,
+  sort: FileSortField = 'name',
+  order: 'asc' | 'desc' = 'asc'
+): Promise<FileItem[]> {
+  const params = new URLSearchParams({ path, sort, order });
+  const response = await fetch(`${API_BASE}/remotes/${remoteName}/browse-rich?${params}`);
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  const data = await response.json();
+  return data.items || [];
+}
+
+export async function uploadFile(
+  remoteName: string,
+  path: string,
+  file: File
+): Promise<{ success: boolean; path: string; size: number }> {
+  const params = new URLSearchParams({ path });
+  const body = new FormData();
+  body.append('file', file);
+
+  const response = await fetch(`${API_BASE}/remotes/${remoteName}/upload?${params}`, {
+    method: 'POST',
+    body,
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export interface FilePreview {
+  path: string;
+  content?: string;
+  hex_dump?: string;
+  is_binary: boolean;
+  mime_type: string;
+  size: number;
+  truncated: boolean;
+}
+
+export async function previewFile(remoteName: string, path: string): Promise<FilePreview> {
+  // The path travels as URL-safe base64 so slashes survive routing
+  const encoded = btoa(unescape(encodeURIComponent(path)))
+    .replace(/\+/g, '-')
+    .replace(/\//g, '_')
+    .replace(/=+$/, '');
+  const response = await fetch(`${API_BASE}/remotes/${remoteName}/files/${encoded}/preview`);
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export async function importGitignore(file: File): Promise<{ excludes: string[]; skipped: string[] }> {
+  const body = new FormData();
+  body.append('file', file);
+
+  const response = await fetch(`${API_BASE}/excludes/import-gitignore`, {
+    method: 'POST',
+    body,
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
 // Migrations API
 export async function startMigration(options: MigrationOptions): Promise<MigrationJob> {
   const response = await fetch(`${API_BASE}/migrations`, {
@@ -134,6 +329,60 @@ export async function startMigration(options: MigrationOptions): Promise<Migrati
   return await response.json();
 }
 
+export async function startIncrementalMigration(options: MigrationOptions): Promise<IncrementalMigrationJob> {
+  const response = await fetch(`${API_BASE}/migrations/incremental`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(options),
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export interface BatchMigration {
+  batch_id: string;
+  sub_job_ids: string[];
+  start_time?: string;
+  status: string;
+  jobs: {
+    job_id: string;
+    status?: string;
+    command?: string;
+    dest_remote?: string;
+    dest_path?: string;
+    total_bytes?: number;
+    total_files?: number;
+  }[];
+}
+
+export async function startBatchMigration(options: MigrationOptions): Promise<BatchMigration> {
+  const response = await fetch(`${API_BASE}/migrations/batch`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(options),
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export async function getBatchMigration(id: string): Promise<BatchMigration> {
+  const response = await fetch(`${API_BASE}/migrations/batch/${id}`);
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
 export async function listMigrations(): Promise<{
   active: MigrationJob[];
   history: MigrationHistory[];
@@ -149,7 +398,7 @@ export async function listHistory(): Promise<MigrationHistory[]> {
 }
 
 export async function clearHistory(): Promise<void> {
-  const response = await fetch(`${API_BASE}/history`, {
+  const response = await fetch(`${API_BASE}/history?confirm=true`, {
     method: 'DELETE',
   });
 
@@ -163,10 +412,101 @@ export async function getHistory(id: string): Promise<MigrationHistory> {
   return await response.json();
 }
 
+export async function checkReplayFeasibility(id: string): Promise<{
+  feasible: boolean;
+  missing_remotes: string[];
+}> {
+  const response = await fetch(`${API_BASE}/history/${id}/replay-feasibility`);
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+// The SMTP password of the notification email is not kept in history: pass it
+// again, or the replay runs without the notification
+export async function replayHistory(id: string, emailPassword?: string): Promise<MigrationJob & {
+  replay_of_id: string;
+  warnings: string[];
+}> {
+  const response = await fetch(`${API_BASE}/history/${id}/replay`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ email_password: emailPassword ?? '' }),
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export async function retryMigration(id: string, emailPassword?: string): Promise<MigrationJob & {
+  retry_of_id: string;
+  retry_root_id: string;
+  retry_count: number;
+  ignore_errors: boolean;
+  warnings: string[];
+}> {
+  const response = await fetch(`${API_BASE}/migrations/${id}/retry`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ email_password: emailPassword ?? '' }),
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export async function cleanupMigration(id: string): Promise<CleanupResult> {
+  const response = await fetch(`${API_BASE}/migrations/${id}/cleanup`, {
+    method: 'POST',
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export async function verifyMigration(id: string): Promise<{ verify_job_id: string; status: string }> {
+  const response = await fetch(`${API_BASE}/migrations/${id}/verify`, {
+    method: 'POST',
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+export async function getVerification(id: string): Promise<VerifyJob> {
+  const response = await fetch(`${API_BASE}/verifications/${id}`);
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
 export interface LiveStats {
   total_bytes: number;
   total_files: number;
   transfer_speed: string;
+  percent_complete?: number;
+  eta_seconds?: number;
+  checks_done?: number;
+  checks_total?: number;
+  current_file?: string;
 }
 
 // Stream migration output
@@ -208,3 +548,50 @@ export function streamMigrationOutput(
   };
 }
 
+// Notifications API
+
+export async function sendTestEmail(config: EmailConfig): Promise<{
+  success: boolean;
+  message?: string;
+  error?: string;
+}> {
+  const response = await fetch(`${API_BASE}/notify/test-email`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(config),
+  });
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}
+
+// TLS API
+
+export interface SSLInfo {
+  common_name: string;
+  issuer: string;
+  not_after: string;
+  days_until_expiry: number;
+  is_expired: boolean;
+  is_self_signed: boolean;
+  sans?: string[];
+}
+
+export async function checkSSL(host: string, port = 443): Promise<{
+  host: string;
+  port: number;
+  ssl: SSLInfo;
+  warnings: string[];
+}> {
+  const params = new URLSearchParams({ host, port: String(port) });
+  const response = await fetch(`${API_BASE}/ssl/check?${params}`);
+
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+
+  return await response.json();
+}